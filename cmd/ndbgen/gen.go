@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+var initialisms = map[string]string{
+	"id": "ID", "uuid": "UUID", "url": "URL", "uri": "URI", "api": "API",
+	"http": "HTTP", "json": "JSON", "sql": "SQL", "ip": "IP",
+}
+
+var goTypes = map[ndb.SchemaFieldType]string{
+	ndb.FIELD_SMALL_INT:    "int16",
+	ndb.FIELD_SMALL_SERIAL: "int16",
+	ndb.FIELD_INT:          "int32",
+	ndb.FIELD_SERIAL:       "int32",
+	ndb.FIELD_BIG_INT:      "int64",
+	ndb.FIELD_BIG_SERIAL:   "int64",
+	ndb.FIELD_VARCHAR:      "string",
+	ndb.FIELD_TEXT:         "string",
+	ndb.FIELD_UUID:         "string",
+	ndb.FIELD_BOOLEAN:      "bool",
+	ndb.FIELD_TIMESTAMP:    "time.Time",
+	ndb.FIELD_JSONB:        "json.RawMessage",
+	ndb.FIELD_FLOAT:        "float64",
+	ndb.FIELD_DOUBLE:       "float64",
+
+	ndb.FIELD_SMALL_INT_ARRAY: "[]int16",
+	ndb.FIELD_INT_ARRAY:       "[]int32",
+	ndb.FIELD_BIG_INT_ARRAY:   "[]int64",
+	ndb.FIELD_UUID_ARRAY:      "[]string",
+	ndb.FIELD_TEXT_ARRAY:      "[]string",
+	ndb.FIELD_BOOLEAN_ARRAY:   "[]bool",
+	ndb.FIELD_TIMESTAMP_ARRAY: "[]time.Time",
+	ndb.FIELD_JSONB_ARRAY:     "[]json.RawMessage",
+	ndb.FIELD_FLOAT_ARRAY:     "[]float64",
+	ndb.FIELD_DOUBLE_ARRAY:    "[]float64",
+}
+
+// generate renders the gofmt-ed source of pkg for schemas, sorted by name.
+func generate(pkg string, schemas []*ndb.Schema) ([]byte, error) {
+	schemas = slices.Clone(schemas)
+	slices.SortFunc(schemas, func(a, b *ndb.Schema) int { return strings.Compare(a.PName, b.PName) })
+
+	var body bytes.Buffer
+	imports := map[string]bool{}
+
+	for _, s := range schemas {
+		if err := writeSchema(&body, s, imports); err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+	src.WriteString("// Code generated by ndbgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg)
+
+	if len(schemas) > 0 {
+		src.WriteString("import (\n")
+		for _, imp := range slices.Sorted(maps.Keys(imports)) {
+			fmt.Fprintf(&src, "\t%q\n", imp)
+		}
+		src.WriteString("\n\t\"github.com/nitsugaro/go-ndb\"\n)\n\n")
+	}
+	src.Write(body.Bytes())
+
+	return format.Source(src.Bytes())
+}
+
+func writeSchema(w *bytes.Buffer, s *ndb.Schema, imports map[string]bool) error {
+	name := goName(s.PName)
+
+	fmt.Fprintf(w, "// %s is a row of the %s schema.\n", name, s.PName)
+	if s.PComment != "" {
+		fmt.Fprintf(w, "//\n// %s\n", s.PComment)
+	}
+	fmt.Fprintf(w, "type %s struct {\n", name)
+
+	for _, f := range s.PFields {
+		typ, ok := goTypes[f.PType]
+		if !ok {
+			return fmt.Errorf("schema '%s': field '%s': unsupported type %s", s.PName, f.PName, f.PType)
+		}
+
+		switch {
+		case strings.Contains(typ, "time."):
+			imports["time"] = true
+		case strings.Contains(typ, "json."):
+			imports["encoding/json"] = true
+		}
+
+		// NULL needs a pointer unless the zero value is already nil
+		if f.PNullable && !strings.HasPrefix(typ, "[]") && typ != "json.RawMessage" {
+			typ = "*" + typ
+		}
+
+		for _, line := range fieldComments(f) {
+			fmt.Fprintf(w, "\t// %s\n", line)
+		}
+		fmt.Fprintf(w, "\t%s %s `json:\"%s\"`\n", goName(f.PName), typ, f.PName)
+	}
+	w.WriteString("}\n\n")
+
+	w.WriteString("const (\n")
+	fmt.Fprintf(w, "\t%sTable = %q\n\n", name, s.PName)
+	for _, f := range s.PFields {
+		fmt.Fprintf(w, "\t%sCol%s = %q\n", name, goName(f.PName), f.PName)
+	}
+	w.WriteString(")\n\n")
+
+	for _, op := range []string{"Read", "Create", "Update", "Delete"} {
+		fmt.Fprintf(w, "func New%s%sQuery() *ndb.Query { return ndb.New%sQuery(%sTable) }\n\n", name, op, op, name)
+	}
+
+	return nil
+}
+
+func fieldComments(f *ndb.SchemaField) []string {
+	var lines []string
+	for _, text := range []string{f.PComment, f.PDescription} {
+		if text != "" {
+			lines = append(lines, strings.Split(text, "\n")...)
+		}
+	}
+
+	if fk := f.PForeignKey; fk != nil {
+		ref := fmt.Sprintf("References %s.%s", fk.PSchema, fk.PColumn)
+		if fk.POnDelete != "" {
+			ref += " ON DELETE " + string(fk.POnDelete)
+		}
+		if fk.POnUpdate != "" {
+			ref += " ON UPDATE " + string(fk.POnUpdate)
+		}
+		lines = append(lines, ref+".")
+	}
+
+	return lines
+}
+
+// goName turns a snake_case SQL name into an exported Go identifier: user_id → UserID.
+func goName(sqlName string) string {
+	var b strings.Builder
+	for part := range strings.FieldsFuncSeq(sqlName, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if up, ok := initialisms[strings.ToLower(part)]; ok {
+			b.WriteString(up)
+			continue
+		}
+		first, size := utf8.DecodeRuneInString(part)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(part[size:])
+	}
+
+	// only an upper case first letter exports the name (digits, or letters without case)
+	name := b.String()
+	if first, _ := utf8.DecodeRuneInString(name); !unicode.IsUpper(first) {
+		name = "X" + name
+	}
+	return name
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"user_id":      "UserID",
+		"api_url":      "APIURL",
+		"public_uuid":  "PublicUUID",
+		"created_at":   "CreatedAt",
+		"2fa_enabled":  "X2faEnabled",
+		"überprüfung":  "Überprüfung",
+		"ñandú_id":     "ÑandúID",
+		"名前":           "X名前",
+		"":             "X",
+		"order--items": "OrderItems",
+	} {
+		if got := goName(in); got != want {
+			t.Fatalf("goName(%q) expected=%q actual=%q", in, want, got)
+		}
+	}
+}
+
+func TestFieldComments(t *testing.T) {
+	f := ndb.NewSchema("orders").
+		NewField("user_id").Type(ndb.FIELD_BIG_INT).Description("Buyer").
+		NewFK("users", "id").OnDelete(ndb.CASCADE).DoneFK().DoneField().
+		GetField("user_id")
+
+	lines := fieldComments(f)
+	if len(lines) != 2 || lines[0] != "Buyer" || lines[1] != "References users.id ON DELETE CASCADE." {
+		t.Fatalf("comments_mismatch lines=%q", lines)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	orders := ndb.NewSchema("orders").
+		NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField().
+		NewField("user_id").Type(ndb.FIELD_BIG_INT).NewFK("users", "id").OnDelete(ndb.CASCADE).DoneFK().DoneField().
+		NewField("api_url").Type(ndb.FIELD_TEXT).Nullable().DoneField().
+		NewField("tags").Type(ndb.FIELD_TEXT_ARRAY).Nullable().DoneField().
+		NewField("created_at").Type(ndb.FIELD_TIMESTAMP).DoneField()
+
+	src, err := generate("models", []*ndb.Schema{orders})
+	if err != nil {
+		t.Fatalf("generate_error: %v", err)
+	}
+
+	// gofmt alignment aside: compare with single spaces
+	out := strings.Join(strings.Fields(string(src)), " ")
+	for _, part := range []string{
+		"package models",
+		`import ( "time" "github.com/nitsugaro/go-ndb" )`,
+		"type Orders struct {",
+		"ID int64 `json:\"id\"`",
+		"// References users.id ON DELETE CASCADE. UserID int64 `json:\"user_id\"`",
+		"APIURL *string `json:\"api_url\"`",
+		"Tags []string `json:\"tags\"`",
+		"CreatedAt time.Time `json:\"created_at\"`",
+		`OrdersColAPIURL = "api_url"`,
+		"func NewOrdersReadQuery() *ndb.Query { return ndb.NewReadQuery(OrdersTable) }",
+	} {
+		if !strings.Contains(out, part) {
+			t.Fatalf("generated_source_missing part=%q source=\n%s", part, src)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Command ndbgen generates Go structs, column name constants and query constructors
+// from the schemas stored in an nstore folder, the one ndb.schema.folder points to.
+//
+//	go run github.com/nitsugaro/go-ndb/cmd/ndbgen -schemas ./schemas -pkg models -out models/ndb_gen.go
+//
+// Without -schemas the folder is read from ndb.schema.folder in .config.json.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	goconf "github.com/nitsugaro/go-conf"
+	"github.com/nitsugaro/go-ndb"
+	"github.com/nitsugaro/go-nstore"
+)
+
+func main() {
+	var (
+		folder = flag.String("schemas", "", "nstore folder holding the schemas (default ndb.schema.folder)")
+		pkg    = flag.String("pkg", "models", "package name of the generated file")
+		out    = flag.String("out", "", "output file (default stdout)")
+	)
+	flag.Parse()
+
+	if *folder == "" {
+		_ = goconf.LoadConfig()
+		*folder = goconf.GetOpField("ndb.schema.folder", "schemas")
+	}
+
+	storage, err := nstore.New[*ndb.Schema](*folder)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := storage.LoadFromDisk(); err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := generate(*pkg, storage.ListOfCache())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
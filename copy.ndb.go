@@ -0,0 +1,345 @@
+package ndb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type CopyFormat string
+
+const (
+	COPY_CSV    CopyFormat = "CSV"
+	COPY_NDJSON CopyFormat = "NDJSON"
+)
+
+var ErrUnsupportedCopyFormat = errors.New("unsupported copy format")
+
+// CopyCSVNull marks a NULL field in CSV, as in Postgres COPY; an empty field is an
+// empty string.
+const CopyCSVNull = `\N`
+
+// copyReader yields one record at a time; the first call fixes the column set.
+type copyReader interface {
+	Columns() ([]string, error)
+	Next() (M, error)
+}
+
+type csvCopyReader struct {
+	r    *csv.Reader
+	cols []string
+}
+
+func (c *csvCopyReader) Columns() ([]string, error) {
+	header, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	// ReuseRecord hands the same slice to the next Read: keep a copy of the names
+	c.cols = slices.Clone(header)
+	return c.cols, nil
+}
+
+func (c *csvCopyReader) Next() (M, error) {
+	record, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	row := make(M, len(c.cols))
+	for i, col := range c.cols {
+		if record[i] == CopyCSVNull {
+			row[col] = nil
+			continue
+		}
+		row[col] = record[i]
+	}
+	return row, nil
+}
+
+type ndjsonCopyReader struct {
+	dec   *json.Decoder
+	first M
+}
+
+func (n *ndjsonCopyReader) Columns() ([]string, error) {
+	row, err := n.Next()
+	if err != nil {
+		return nil, err
+	}
+	n.first = row
+
+	cols := make([]string, 0, len(row))
+	for k := range row {
+		cols = append(cols, k)
+	}
+	slices.Sort(cols)
+	return cols, nil
+}
+
+func (n *ndjsonCopyReader) Next() (M, error) {
+	if n.first != nil {
+		row := n.first
+		n.first = nil
+		return row, nil
+	}
+
+	row := M{}
+	if err := n.dec.Decode(&row); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func newCopyReader(r io.Reader, format CopyFormat) (copyReader, error) {
+	switch format {
+	case COPY_CSV:
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+		return &csvCopyReader{r: cr}, nil
+	case COPY_NDJSON:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		return &ndjsonCopyReader{dec: dec}, nil
+	default:
+		return nil, ErrUnsupportedCopyFormat
+	}
+}
+
+// normalizeCopyValue turns decoded values that database/sql cannot bind (JSON objects,
+// CSV-encoded arrays) into the shapes expected by ValidateSchema and the driver.
+func normalizeCopyValue(f *SchemaField, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if _, isArr := arrayBase(f.PType); isArr {
+		if s, ok := v.(string); ok {
+			var arr []any
+			if err := json.Unmarshal([]byte(s), &arr); err != nil {
+				return nil, fmt.Errorf("field '%s': must be a JSON array", f.PName)
+			}
+			return arr, nil
+		}
+	}
+
+	return v, nil
+}
+
+func jsonbCopyValue(v any) (any, error) {
+	switch v.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		return v, nil
+	}
+}
+
+// CopyFrom bulk loads r into the schema table through COPY FROM STDIN. Every record is
+// validated and coerced against the stored schema before being sent. CSV input needs a
+// header row; NDJSON columns are taken from the first record. Returns the copied rows.
+func (dbb *DBBridge) CopyFrom(schemaName string, r io.Reader, format CopyFormat) (int64, error) {
+	schema, ok := dbb.GetSchemaByName(schemaName)
+	if !ok {
+		return 0, ErrSchemaKeyNotFound
+	}
+
+	reader, err := newCopyReader(r, format)
+	if err != nil {
+		return 0, err
+	}
+
+	cols, err := reader.Columns()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	fields := make([]*SchemaField, len(cols))
+	for i, col := range cols {
+		if fields[i] = schema.GetField(col); fields[i] == nil {
+			return 0, fmt.Errorf("%w: '%s'", ErrSchemaKeyNotFound, col)
+		}
+	}
+
+	var total int64
+	err = dbb.runInTransaction(func(tx *DBBridge) (err error) {
+		copySQL := pq.CopyIn(dbb.schemaPrefix+schemaName, cols...)
+		defer func(start time.Time) { tx.runAfterExecute(copySQL, nil, start, total, err) }(time.Now())
+
+		stmt, err := tx.trx.PrepareContext(tx.Context(), copySQL)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		tx.markWrite(copySQL)
+
+		values := make([]any, len(cols))
+		for {
+			row, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("record %d: %w", total+1, err)
+			}
+
+			if len(row) != len(cols) {
+				return fmt.Errorf("record %d: expected %d columns, got %d", total+1, len(cols), len(row))
+			}
+
+			for _, f := range fields {
+				if row[f.PName], err = normalizeCopyValue(f, row[f.PName]); err != nil {
+					return fmt.Errorf("record %d: %w", total+1, err)
+				}
+			}
+
+			if err := dbb.ValidateSchema(schemaName, CREATE, row); err != nil {
+				return fmt.Errorf("record %d: %w", total+1, err)
+			}
+
+			for i, f := range fields {
+				v, has := row[f.PName]
+				if !has {
+					return fmt.Errorf("record %d: missing column '%s'", total+1, f.PName)
+				}
+				if f.PType == FIELD_JSONB {
+					if v, err = jsonbCopyValue(v); err != nil {
+						return fmt.Errorf("record %d: %w", total+1, err)
+					}
+				}
+				values[i] = v
+			}
+
+			if _, err := stmt.ExecContext(tx.Context(), values...); err != nil {
+				return fmt.Errorf("record %d: %w", total+1, tx.classifyError(err))
+			}
+			total++
+		}
+
+		_, err = stmt.ExecContext(tx.Context())
+		return tx.classifyError(err)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// runInTransaction reuses the current transaction or opens a new one.
+func (dbb *DBBridge) runInTransaction(fn func(tx *DBBridge) error) error {
+	if dbb.trx != nil {
+		return fn(dbb)
+	}
+	// the input stream is consumed by the first attempt: it cannot be re-run
+	return dbb.TransactionWith(&TxOptions{MaxRetries: NoTxRetries}, fn)
+}
+
+// CopyTo streams the rows of a read query to w as CSV (with header) or NDJSON,
+// one row at a time. Without an explicit Limit the whole result is exported.
+// Returns the written rows.
+func (dbb *DBBridge) CopyTo(readQuery *Query, w io.Writer, format CopyFormat) (total int64, err error) {
+	if format != COPY_CSV && format != COPY_NDJSON {
+		return 0, ErrUnsupportedCopyFormat
+	}
+
+	if readQuery.PLimit == 0 && !readQuery.unbounded {
+		readQuery.unbounded = true
+		defer func() { readQuery.unbounded = false }()
+	}
+
+	query, args, err := dbb.BuildReadQuery(readQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	reader := dbb.readBridge(readQuery)
+	if format == COPY_NDJSON {
+		return reader.ExecuteQueryJSON(w, true, query, args...)
+	}
+
+	defer func(start time.Time) { reader.runAfterExecute(query, args, start, total, err) }(time.Now())
+
+	rows, err := reader.queryRows(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, plans, ptrs, err := planColumns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(cols); err != nil {
+		return 0, err
+	}
+
+	record := make([]string, len(cols))
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return total, err
+		}
+
+		for i := range plans {
+			v, err := readPlannedValueForCSV(plans[i])
+			if err != nil {
+				return total, fmt.Errorf("col=%s type=%s: %w", cols[i], plans[i].dbTyp, err)
+			}
+			record[i] = v
+		}
+
+		if err := cw.Write(record); err != nil {
+			return total, err
+		}
+		total++
+	}
+
+	if err := rows.Err(); err != nil {
+		return total, reader.classifyError(err)
+	}
+
+	cw.Flush()
+	return total, cw.Error()
+}
+
+func readPlannedValueForCSV(p colPlan) (string, error) {
+	v, err := readPlannedValueForMap(p)
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return CopyCSVNull, nil
+	}
+
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case time.Time:
+		return x.Format(time.RFC3339Nano), nil
+	default:
+		b, err := json.Marshal(x)
+		return string(b), err
+	}
+}
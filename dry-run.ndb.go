@@ -0,0 +1,279 @@
+package ndb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/nitsugaro/go-nstore"
+)
+
+// DryRunStatement is a statement a dry-run bridge received instead of running it.
+type DryRunStatement struct {
+	SQL  string
+	Args []any
+}
+
+// DryRun records the statements of a dry-run pool and answers them with canned rows.
+type DryRun struct {
+	mu         sync.Mutex
+	statements []DryRunStatement
+	respond    func(stmt DryRunStatement) []M
+	fail       func(stmt DryRunStatement) error
+}
+
+// NewDryRunBridge returns a bridge running the whole build pipeline (middlewares,
+// ValidateSchema, Build*Query) whose statements are recorded by the returned DryRun
+// instead of reaching a database.
+func NewDryRunBridge(schemaStorage *nstore.NStorage[*Schema]) (*DBBridge, *DryRun) {
+	db, dr := NewDryRunDB()
+	return NewBridge(&NBridge{DB: db, SchemaStorage: schemaStorage}), dr
+}
+
+// NewDryRunDB returns a *sql.DB recording every statement, to build dry-run bridges
+// with any NBridge config (schema prefix, middlewares, caches...).
+func NewDryRunDB() (*sql.DB, *DryRun) {
+	dr := &DryRun{}
+	return sql.OpenDB(dryRunConnector{dr}), dr
+}
+
+// Respond sets the rows returned for each statement; exec statements report
+// their length as rows affected. Transaction statements are not answered.
+func (dr *DryRun) Respond(fn func(stmt DryRunStatement) []M) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.respond = fn
+}
+
+// Fail makes every statement for which fn returns an error fail with it, once
+// recorded. Transaction statements never fail.
+func (dr *DryRun) Fail(fn func(stmt DryRunStatement) error) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.fail = fn
+}
+
+// SetRows answers every statement with rows.
+func (dr *DryRun) SetRows(rows ...M) {
+	dr.Respond(func(DryRunStatement) []M { return rows })
+}
+
+func (dr *DryRun) Statements() []DryRunStatement {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	return slices.Clone(dr.statements)
+}
+
+// Last returns the last recorded statement, the zero value when there is none.
+func (dr *DryRun) Last() DryRunStatement {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	if len(dr.statements) == 0 {
+		return DryRunStatement{}
+	}
+	return dr.statements[len(dr.statements)-1]
+}
+
+func (dr *DryRun) Reset() {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.statements = nil
+}
+
+func (dr *DryRun) record(query string, args []driver.NamedValue) ([]M, error) {
+	stmt := DryRunStatement{SQL: query, Args: make([]any, len(args))}
+	for i, a := range args {
+		stmt.Args[i] = a.Value
+	}
+
+	dr.mu.Lock()
+	dr.statements = append(dr.statements, stmt)
+	respond, fail := dr.respond, dr.fail
+	dr.mu.Unlock()
+
+	if fail != nil {
+		if err := fail(stmt); err != nil {
+			return nil, err
+		}
+	}
+	if respond == nil {
+		return nil, nil
+	}
+	return respond(stmt), nil
+}
+
+func (dr *DryRun) recordTx(query string) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.statements = append(dr.statements, DryRunStatement{SQL: query})
+}
+
+//########## DRIVER ###########
+
+type dryRunConnector struct{ dr *DryRun }
+
+func (c dryRunConnector) Connect(context.Context) (driver.Conn, error) { return &dryRunConn{c.dr}, nil }
+func (c dryRunConnector) Driver() driver.Driver                        { return dryRunDriver{c.dr} }
+
+type dryRunDriver struct{ dr *DryRun }
+
+func (d dryRunDriver) Open(string) (driver.Conn, error) { return &dryRunConn{d.dr}, nil }
+
+type dryRunConn struct{ dr *DryRun }
+
+func (c *dryRunConn) Prepare(query string) (driver.Stmt, error) { return &dryRunStmt{c, query}, nil }
+func (c *dryRunConn) Close() error                              { return nil }
+func (c *dryRunConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *dryRunConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.dr.recordTx("BEGIN")
+	return c, nil
+}
+
+func (c *dryRunConn) Commit() error {
+	c.dr.recordTx("COMMIT")
+	return nil
+}
+
+func (c *dryRunConn) Rollback() error {
+	c.dr.recordTx("ROLLBACK")
+	return nil
+}
+
+// CheckNamedValue keeps the args as the builders produced them.
+func (c *dryRunConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *dryRunConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.dr.record(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newDryRunRows(rows)
+}
+
+func (c *dryRunConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.dr.record(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+type dryRunStmt struct {
+	c     *dryRunConn
+	query string
+}
+
+func (s *dryRunStmt) Close() error                             { return nil }
+func (s *dryRunStmt) NumInput() int                            { return -1 }
+func (s *dryRunStmt) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (s *dryRunStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *dryRunStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *dryRunStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.c.ExecContext(ctx, s.query, args)
+}
+
+func (s *dryRunStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.c.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+// dryRunRows serves canned rows; columns are the sorted union of their keys and
+// typed after the first non-nil value, so the column plans scan them as usual.
+type dryRunRows struct {
+	cols  []string
+	types []string
+	rows  [][]driver.Value
+	next  int
+}
+
+func newDryRunRows(rows []M) (*dryRunRows, error) {
+	keys := map[string]struct{}{}
+	for _, row := range rows {
+		for k := range row {
+			keys[k] = struct{}{}
+		}
+	}
+
+	r := &dryRunRows{cols: slices.Sorted(maps.Keys(keys))}
+	r.types = make([]string, len(r.cols))
+
+	for _, row := range rows {
+		values := make([]driver.Value, len(r.cols))
+		for i, col := range r.cols {
+			v, typ, err := dryRunValue(row[col])
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+			if r.types[i] == "" {
+				r.types[i] = typ
+			}
+		}
+		r.rows = append(r.rows, values)
+	}
+
+	return r, nil
+}
+
+func dryRunValue(v any) (driver.Value, string, error) {
+	if v == nil {
+		return nil, "", nil
+	}
+
+	if dv, err := driver.DefaultParameterConverter.ConvertValue(v); err == nil {
+		switch dv.(type) {
+		case int64:
+			return dv, "INT8", nil
+		case float64:
+			return dv, "FLOAT8", nil
+		case bool:
+			return dv, "BOOL", nil
+		case string:
+			return dv, "TEXT", nil
+		case time.Time:
+			return dv, "TIMESTAMPTZ", nil
+		default:
+			return dv, "", nil
+		}
+	}
+
+	b, err := json.Marshal(v)
+	return b, "JSONB", err
+}
+
+func (r *dryRunRows) Columns() []string { return r.cols }
+func (r *dryRunRows) Close() error      { return nil }
+
+func (r *dryRunRows) ColumnTypeDatabaseTypeName(i int) string { return r.types[i] }
+
+func (r *dryRunRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
@@ -0,0 +1,144 @@
+package ndb
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUniqueViolation     = errors.New("unique constraint violation")
+	ErrForeignKeyViolation = errors.New("foreign key constraint violation")
+	ErrNotNullViolation    = errors.New("not null constraint violation")
+	ErrCheckViolation      = errors.New("check constraint violation")
+	ErrSerialization       = errors.New("transaction serialization failure")
+)
+
+var pgErrorKinds = map[pq.ErrorCode]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23502": ErrNotNullViolation,
+	"23514": ErrCheckViolation,
+	"40001": ErrSerialization,
+	"40P01": ErrSerialization,
+}
+
+// detail of unique and foreign key violations: Key (col_a, col_b)=(...) ...
+var pgDetailKeyRegex = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// DBError is a driver error classified by its PostgreSQL code. errors.Is matches
+// its Kind (ErrUniqueViolation, ...) and errors.As still reaches the *pq.Error.
+type DBError struct {
+	Kind       error  `json:"-"`
+	Table      string `json:"table,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
+	// Columns are the violated columns; Fields holds the ones known by the Schema
+	Columns []string       `json:"columns,omitempty"`
+	Fields  []*SchemaField `json:"-"`
+	Detail  string         `json:"detail,omitempty"`
+
+	err *pq.Error
+}
+
+func (e *DBError) Error() string {
+	msg := e.Kind.Error()
+	if e.Table != "" {
+		msg += " on " + e.Table
+	}
+	if names := e.DisplayNames(); len(names) != 0 {
+		msg += " (" + strings.Join(names, ", ") + ")"
+	}
+	return msg
+}
+
+func (e *DBError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *DBError) Unwrap() error {
+	return e.err
+}
+
+// DisplayNames returns the PDisplayName of each violated field, its column name
+// when the schema has none.
+func (e *DBError) DisplayNames() []string {
+	names := make([]string, 0, len(e.Columns))
+	for _, col := range e.Columns {
+		name := col
+		for _, f := range e.Fields {
+			if f.PName == col && f.PDisplayName != "" {
+				name = f.PDisplayName
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// HTTPStatus maps the error to the status a REST layer should answer with:
+// 409 for conflicts and serialization failures, 422 for invalid data.
+func (e *DBError) HTTPStatus() int {
+	switch e.Kind {
+	case ErrUniqueViolation, ErrSerialization:
+		return http.StatusConflict
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// classifyError turns a *pq.Error with a known code into a *DBError, resolving the
+// table and columns back to the bridge schemas. Any other error is returned as is.
+func (dbb *DBBridge) classifyError(err error) error {
+	var pqErr *pq.Error
+	if err == nil || !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var dbErr *DBError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+
+	kind, ok := pgErrorKinds[pqErr.Code]
+	if !ok {
+		return err
+	}
+
+	dbErr = &DBError{
+		Kind:       kind,
+		Table:      strings.TrimPrefix(pqErr.Table, dbb.schemaPrefix),
+		Constraint: pqErr.Constraint,
+		Detail:     pqErr.Detail,
+		err:        pqErr,
+	}
+
+	switch {
+	case pqErr.Column != "":
+		dbErr.Columns = []string{pqErr.Column}
+	case pgDetailKeyRegex.MatchString(pqErr.Detail):
+		for col := range strings.SplitSeq(pgDetailKeyRegex.FindStringSubmatch(pqErr.Detail)[1], ",") {
+			dbErr.Columns = append(dbErr.Columns, strings.Trim(strings.TrimSpace(col), `"`))
+		}
+	case kind == ErrCheckViolation:
+		// column checks are named <table>_<column>_check by PostgreSQL
+		col := strings.TrimSuffix(strings.TrimPrefix(pqErr.Constraint, pqErr.Table+"_"), "_check")
+		if col != pqErr.Constraint {
+			dbErr.Columns = []string{col}
+		}
+	}
+
+	if dbErr.Table != "" && dbb.schemaStorage != nil {
+		if schema, ok := dbb.GetSchemaByName(dbErr.Table); ok {
+			for _, col := range dbErr.Columns {
+				if f := schema.GetField(col); f != nil {
+					dbErr.Fields = append(dbErr.Fields, f)
+				}
+			}
+		}
+	}
+
+	return dbErr
+}
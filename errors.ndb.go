@@ -21,4 +21,6 @@ var (
 	ErrMissingWhereQuery        = errors.New("query operation must have a where condition")
 	ErrTableNotAllowedQuery     = errors.New("query table is forbidden on this bridge")
 	ErrEmptyPayloadQuery        = errors.New("query operation has an empty payload")
+	ErrInvalidConflict          = errors.New("invalid on conflict clause")
+	ErrNegativeLimit            = errors.New("query limit cannot be negative")
 )
@@ -0,0 +1,115 @@
+package ndb
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var ErrEmptyPlan = errors.New("explain returned no plan")
+
+// PlanNode is a node of the tree returned by EXPLAIN (FORMAT JSON). The common
+// properties are typed; Details keeps every property PostgreSQL reported.
+type PlanNode struct {
+	NodeType     string      `json:"Node Type"`
+	RelationName string      `json:"Relation Name,omitempty"`
+	Alias        string      `json:"Alias,omitempty"`
+	IndexName    string      `json:"Index Name,omitempty"`
+	JoinType     string      `json:"Join Type,omitempty"`
+	IndexCond    string      `json:"Index Cond,omitempty"`
+	Filter       string      `json:"Filter,omitempty"`
+	StartupCost  float64     `json:"Startup Cost"`
+	TotalCost    float64     `json:"Total Cost"`
+	PlanRows     float64     `json:"Plan Rows"`
+	PlanWidth    int         `json:"Plan Width"`
+	Plans        []*PlanNode `json:"Plans,omitempty"`
+	Details      M           `json:"-"`
+}
+
+func (p *PlanNode) UnmarshalJSON(data []byte) error {
+	type plain PlanNode
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &p.Details); err != nil {
+		return err
+	}
+	delete(p.Details, "Plans")
+
+	return nil
+}
+
+// Walk calls fn for the node and all its children, depth first.
+func (p *PlanNode) Walk(fn func(node *PlanNode, depth int)) {
+	p.walk(fn, 0)
+}
+
+func (p *PlanNode) walk(fn func(node *PlanNode, depth int), depth int) {
+	fn(p, depth)
+	for _, child := range p.Plans {
+		child.walk(fn, depth+1)
+	}
+}
+
+// Explain builds the query and returns the plan PostgreSQL would run it with.
+// The statement is not executed.
+func (dbb *DBBridge) Explain(q *Query) (*PlanNode, error) {
+	query, args, err := dbb.BuildQuery(q)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.typ == READ {
+		return dbb.readBridge(q).explainSQL(query, args...)
+	}
+	return dbb.explainSQL(query, args...)
+}
+
+// BuildQuery builds the SQL of any query type, with RETURNING when it has fields.
+func (dbb *DBBridge) BuildQuery(q *Query) (string, []any, error) {
+	switch q.typ {
+	case READ:
+		return dbb.BuildReadQuery(q)
+	case CREATE:
+		return dbb.BuildCreateQuery(q)
+	case UPDATE:
+		return dbb.BuildUpdateQuery(q, len(q.PFields) != 0)
+	case DELETE:
+		return dbb.BuildDeleteQuery(q, len(q.PFields) != 0)
+	}
+
+	return "", nil, ErrInvalidQueryType
+}
+
+// explainSQL runs EXPLAIN (FORMAT JSON) without going through the AfterExecute hooks.
+func (dbb *DBBridge) explainSQL(query string, args ...any) (*PlanNode, error) {
+	rows, err := dbb.queryDirect("EXPLAIN (FORMAT JSON) "+query, args...)
+	if err != nil {
+		return nil, dbb.classifyError(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, dbb.classifyError(err)
+		}
+		return nil, ErrEmptyPlan
+	}
+
+	var raw []byte
+	if err := rows.Scan(&raw); err != nil {
+		return nil, err
+	}
+
+	var plans []struct {
+		Plan *PlanNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(raw, &plans); err != nil {
+		return nil, err
+	}
+
+	if len(plans) == 0 || plans[0].Plan == nil {
+		return nil, ErrEmptyPlan
+	}
+	return plans[0].Plan, nil
+}
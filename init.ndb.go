@@ -1,15 +1,32 @@
 package ndb
 
 import (
+	"time"
+
 	goconf "github.com/nitsugaro/go-conf"
 	"github.com/nitsugaro/go-ndb/cache"
 )
 
 var logEnabled = false
 
+var cursorSecret = ""
+
+var (
+	slowQueryThreshold      = 500 * time.Millisecond
+	slowQueryExplain        = false
+	slowQueryExplainTimeout = 2 * time.Second
+	// EXPLAINs of slow statements running at once, per SlowQueryLog hook
+	slowQueryExplainConcurrency = 2
+)
+
 func init() {
 	goconf.OnLoad(func() {
 		cache.SetCacheLimit(goconf.GetOpField("ndb.schema.cache_regex_limit", 100))
 		logEnabled = goconf.GetOpField("ndb.logging", false)
+		cursorSecret = goconf.GetOpField("ndb.cursor_secret", "")
+		slowQueryThreshold = time.Duration(goconf.GetOpField("ndb.slow_query.threshold_ms", 500)) * time.Millisecond
+		slowQueryExplain = goconf.GetOpField("ndb.slow_query.explain", false)
+		slowQueryExplainTimeout = time.Duration(goconf.GetOpField("ndb.slow_query.explain_timeout_ms", 2000)) * time.Millisecond
+		slowQueryExplainConcurrency = goconf.GetOpField("ndb.slow_query.explain_concurrency", 2)
 	})
 }
@@ -0,0 +1,287 @@
+package ndb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var ErrNotFoundIntrospectTable = errors.New("table not found in the current schema")
+
+var udtFieldTypes = map[string]SchemaFieldType{
+	"int2":        FIELD_SMALL_INT,
+	"int4":        FIELD_INT,
+	"int8":        FIELD_BIG_INT,
+	"varchar":     FIELD_VARCHAR,
+	"text":        FIELD_TEXT,
+	"uuid":        FIELD_UUID,
+	"bool":        FIELD_BOOLEAN,
+	"timestamp":   FIELD_TIMESTAMP,
+	"timestamptz": FIELD_TIMESTAMP,
+	"jsonb":       FIELD_JSONB,
+	"json":        FIELD_JSONB,
+	"float8":      FIELD_DOUBLE, // FIELD_FLOAT columns are float8 too
+}
+
+var serialFieldTypes = map[SchemaFieldType]SchemaFieldType{
+	FIELD_SMALL_INT: FIELD_SMALL_SERIAL,
+	FIELD_INT:       FIELD_SERIAL,
+	FIELD_BIG_INT:   FIELD_BIG_SERIAL,
+}
+
+var fkRuleCodes = map[string]ForeignKeyRule{
+	"r": RESTRICT,
+	"c": CASCADE,
+	"n": SET_NULL,
+	"d": SET_DEFAULT,
+}
+
+var (
+	checkArrayRegex   = regexp.MustCompile(`ARRAY\[(.*?)\]`)
+	checkLiteralRegex = regexp.MustCompile(`'\{([^}]*)\}'`)
+)
+
+const introspectColumnsSQL = `SELECT c.column_name, c.udt_name, c.is_nullable = 'YES', c.column_default, c.character_maximum_length,
+	col_description(to_regclass(quote_ident(c.table_name)), c.ordinal_position::int)
+FROM information_schema.columns c
+WHERE c.table_schema = current_schema() AND c.table_name = $1
+ORDER BY c.ordinal_position`
+
+const introspectConstraintsSQL = `SELECT con.contype::text,
+	ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(num, ord)
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.num ORDER BY k.ord)::text[],
+	COALESCE(ft.relname, ''),
+	ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(num, ord)
+		JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.num ORDER BY k.ord)::text[],
+	con.confdeltype::text, con.confupdtype::text, pg_get_constraintdef(con.oid)
+FROM pg_constraint con
+LEFT JOIN pg_class ft ON ft.oid = con.confrelid
+WHERE con.conrelid = to_regclass(quote_ident($1))
+ORDER BY con.conname`
+
+// indexes backing a constraint (PK, UNIQUE) are reported by the constraints query
+const introspectIndexesSQL = `SELECT i.indisunique,
+	ARRAY(SELECT a.attname FROM unnest(i.indkey::int2[]) WITH ORDINALITY k(num, ord)
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.num ORDER BY k.ord)::text[]
+FROM pg_index i
+JOIN pg_class ic ON ic.oid = i.indexrelid
+WHERE i.indrelid = to_regclass(quote_ident($1)) AND NOT i.indisprimary
+	AND NOT EXISTS (SELECT 1 FROM pg_constraint con WHERE con.conindid = i.indexrelid)
+ORDER BY ic.relname`
+
+const introspectTablesSQL = `SELECT table_name FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND starts_with(table_name, $1)
+ORDER BY table_name`
+
+// IntrospectTable reads the table of the schema name (the bridge prefix is added)
+// from information_schema and pg_catalog and returns it as a Schema: columns,
+// types, nullability, defaults, PK, unique and regular indexes, FKs, CHECK IN-lists
+// as enum values and comments. Serial columns lose their nextval default. Nothing
+// is saved; see ImportSchema.
+func (dbb *DBBridge) IntrospectTable(name string) (*Schema, error) {
+	table := dbb.schemaPrefix + name
+	s := NewSchema(name)
+
+	err := dbb.catalogRows(introspectColumnsSQL, []any{table}, func(rows *sql.Rows) error {
+		var (
+			column, udt  string
+			nullable     bool
+			def, comment sql.NullString
+			maxLength    sql.NullInt64
+		)
+		if err := rows.Scan(&column, &udt, &nullable, &def, &maxLength, &comment); err != nil {
+			return err
+		}
+
+		f := s.NewField(column)
+		f.PNullable = nullable
+		f.PComment = comment.String
+
+		typ, ok := udtFieldType(udt)
+		if !ok {
+			return fmt.Errorf("table '%s': column '%s': unsupported type %s", table, column, udt)
+		}
+		if serial, ok := serialFieldTypes[typ]; ok && strings.HasPrefix(def.String, "nextval(") {
+			typ, def.Valid = serial, false
+		}
+		f.Type(typ)
+
+		if def.Valid {
+			f.Default(def.String)
+		}
+		if typ == FIELD_VARCHAR && maxLength.Valid {
+			f.Max(int(maxLength.Int64))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(s.PFields) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFoundIntrospectTable, table)
+	}
+
+	err = dbb.catalogRows(introspectConstraintsSQL, []any{table}, func(rows *sql.Rows) error {
+		var (
+			typ, refTable, onDelete, onUpdate, def string
+			cols, refCols                          pq.StringArray
+		)
+		if err := rows.Scan(&typ, &cols, &refTable, &refCols, &onDelete, &onUpdate, &def); err != nil {
+			return err
+		}
+
+		switch {
+		case typ == "p" && len(cols) == 1:
+			s.GetField(cols[0]).PK()
+		case typ == "p":
+			s.CompositePK(cols...)
+		case typ == "u" && len(cols) == 1:
+			s.GetField(cols[0]).Unique()
+		case typ == "u":
+			s.PCompositeUniqueKeys = append(s.PCompositeUniqueKeys, cols)
+		case typ == "f" && len(cols) == 1 && len(refCols) == 1:
+			fk := s.GetField(cols[0]).NewFK(strings.TrimPrefix(refTable, dbb.schemaPrefix), refCols[0])
+			fk.POnDelete = fkRuleCodes[onDelete]
+			fk.POnUpdate = fkRuleCodes[onUpdate]
+		case typ == "c" && len(cols) == 1:
+			s.GetField(cols[0]).PEnumValues = checkEnumValues(def)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbb.catalogRows(introspectIndexesSQL, []any{table}, func(rows *sql.Rows) error {
+		var (
+			unique bool
+			cols   pq.StringArray
+		)
+		if err := rows.Scan(&unique, &cols); err != nil {
+			return err
+		}
+
+		// expression indexes have no plain columns
+		if len(cols) == 0 {
+			return nil
+		}
+		if unique {
+			s.UniqueIndex(cols...)
+		} else {
+			s.Indexes(cols...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbb.catalogRows(`SELECT COALESCE(obj_description(to_regclass(quote_ident($1)), 'pg_class'), '')`, []any{table}, func(rows *sql.Rows) error {
+		return rows.Scan(&s.PComment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// IntrospectAll introspects every table of the current schema whose name starts
+// with the bridge prefix followed by prefix.
+func (dbb *DBBridge) IntrospectAll(prefix string) ([]*Schema, error) {
+	var names []string
+	err := dbb.catalogRows(introspectTablesSQL, []any{dbb.schemaPrefix + prefix}, func(rows *sql.Rows) error {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return err
+		}
+		names = append(names, strings.TrimPrefix(table, dbb.schemaPrefix))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schemas := make([]*Schema, 0, len(names))
+	for _, name := range names {
+		s, err := dbb.IntrospectTable(name)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+
+	return schemas, nil
+}
+
+// ImportSchema saves a schema describing an existing table into the schema storage
+// without running any DDL.
+func (dbb *DBBridge) ImportSchema(schema *Schema) error {
+	if _, ok := dbb.GetSchemaByName(schema.PName); ok {
+		return fmt.Errorf("schema '%s' already exists", schema.PName)
+	}
+
+	return dbb.schemaStorage.Save(schema)
+}
+
+// catalogRows runs an internal catalog query, outside the AfterExecute hooks, and
+// calls scan for each row.
+func (dbb *DBBridge) catalogRows(query string, args []any, scan func(rows *sql.Rows) error) error {
+	rows, err := dbb.queryDirect(query, args...)
+	if err != nil {
+		return dbb.classifyError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return dbb.classifyError(rows.Err())
+}
+
+func udtFieldType(udt string) (SchemaFieldType, bool) {
+	if base, ok := strings.CutPrefix(udt, "_"); ok {
+		typ, ok := udtFieldTypes[base]
+		if !ok {
+			return "", false
+		}
+		for arr, b := range arrayBaseType {
+			if b == typ {
+				return arr, true
+			}
+		}
+		return "", false
+	}
+
+	typ, ok := udtFieldTypes[udt]
+	return typ, ok
+}
+
+// checkEnumValues extracts the values of an IN-list check as pg_get_constraintdef
+// renders it: CHECK ((col)::text = ANY ((ARRAY['a'::character varying, ...])::text[]))
+// or CHECK ((col <@ '{0,1,2}'::smallint[])) for arrays. Quotes and casts are dropped.
+func checkEnumValues(def string) []string {
+	var values []string
+
+	if m := checkArrayRegex.FindStringSubmatch(def); m != nil {
+		for v := range strings.SplitSeq(m[1], ",") {
+			v, _, _ = strings.Cut(strings.TrimSpace(v), "::")
+			values = append(values, strings.Trim(v, "'()"))
+		}
+	} else if m := checkLiteralRegex.FindStringSubmatch(def); m != nil {
+		for v := range strings.SplitSeq(m[1], ",") {
+			values = append(values, strings.Trim(strings.TrimSpace(v), `"`))
+		}
+	}
+
+	return values
+}
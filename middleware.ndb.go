@@ -2,6 +2,7 @@ package ndb
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/fatih/color"
 	goutils "github.com/nitsugaro/go-utils"
@@ -37,6 +38,47 @@ func (d *DBBridge) runPostValidateMiddlewares(query *Query) error {
 	return nil
 }
 
+// QueryEvent describes one execution, reported to the AfterExecute hooks once its
+// rows are consumed. Query is nil for raw ExecuteQuery calls and internal statements.
+type QueryEvent struct {
+	Query        *Query
+	SQL          string
+	Args         []any
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+}
+
+type ExecuteHook = func(event QueryEvent)
+
+// AfterExecute registers a hook run after every statement of the bridge, its
+// copies and its transactions: metrics, tracing spans, slow query logs...
+func (d *DBBridge) AfterExecute(h ExecuteHook) {
+	d.afterExecute = append(d.afterExecute, h)
+}
+
+// observed returns a copy of the bridge that reports q in its QueryEvents.
+func (d *DBBridge) observed(q *Query) *DBBridge {
+	if len(d.afterExecute) == 0 {
+		return d
+	}
+
+	b := *d
+	b.query = q
+	return &b
+}
+
+func (d *DBBridge) runAfterExecute(sql string, args []any, start time.Time, rows int64, err error) {
+	if len(d.afterExecute) == 0 {
+		return
+	}
+
+	event := QueryEvent{Query: d.query, SQL: sql, Args: args, Duration: time.Since(start), RowsAffected: rows, Err: err}
+	for _, h := range d.afterExecute {
+		h(event)
+	}
+}
+
 //########## DEFAULT MIDDLEWARES ###########
 
 // Logs every query operation with its color
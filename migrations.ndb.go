@@ -0,0 +1,399 @@
+package ndb
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nitsugaro/go-nstore"
+)
+
+// MigrationsTable is the schema history: one versioned row per migration applied
+// by Migrate, one unversioned row per schema operation run outside Migrate. It is
+// shared by every bridge of the database, whatever its schema prefix.
+const MigrationsTable = "ndb_migrations"
+
+// LatestMigration as the Migrate target applies every registered migration.
+const LatestMigration int64 = math.MaxInt64
+
+var (
+	ErrIrreversibleMigration = errors.New("migration has no Down step")
+	ErrUnknownMigration      = errors.New("applied migration is not registered")
+	ErrInvalidMigration      = errors.New("invalid migration")
+	ErrMigrationChecksum     = errors.New("applied migration does not match its checksum")
+)
+
+const createMigrationsTableSQL = `CREATE TABLE IF NOT EXISTS "` + MigrationsTable + `" (
+	id BIGSERIAL PRIMARY KEY,
+	version BIGINT UNIQUE,
+	name TEXT NOT NULL,
+	checksum TEXT NOT NULL,
+	alter_fields JSONB NOT NULL,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// the lock lives until the migration (or schema operation) transaction ends
+const migrationLockSQL = `SELECT pg_advisory_xact_lock(hashtext($1))`
+
+// Migration is one versioned change of the database. Up and Down run on a
+// transaction bridge; the schema operations they make there (CreateSchema,
+// ModifySchema, DeleteSchema, Apply) are recorded in the migration row of
+// ndb_migrations. Up is also replayed on a dry-run bridge to verify the checksum
+// of applied migrations, so it should have no side effects outside tx.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *DBBridge) error
+	Down    func(tx *DBBridge) error
+}
+
+// MigrationChange is one schema operation made by a migration: create, alter or drop.
+type MigrationChange struct {
+	Action string        `json:"action"`
+	Schema string        `json:"schema"`
+	Fields []*AlterField `json:"fields,omitempty"`
+}
+
+// AppliedMigration is a row of ndb_migrations. Checksum is the sha256 of the DDL
+// generated by the migration. Version is zero for a schema operation run outside
+// Migrate, named after its action and schema.
+type AppliedMigration struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	Changes   []*MigrationChange
+	AppliedAt time.Time
+}
+
+type migrationLog struct {
+	ddl     []string
+	changes []*MigrationChange
+	// replay runs Up only to compute its checksum, see migrationChecksums
+	replay bool
+}
+
+// AddMigrations registers migrations to be run by Migrate.
+func (dbb *DBBridge) AddMigrations(migrations ...*Migration) {
+	dbb.migrations = append(dbb.migrations, migrations...)
+}
+
+// Migrate moves the database to targetVersion: applied migrations above it are
+// reverted with their Down in reverse order, then pending ones up to it are
+// applied in version order. Each migration runs in its own transaction holding a
+// Postgres advisory lock, so a concurrent instance waits and then skips what was
+// already applied. An applied migration whose Up now generates other DDL fails
+// with ErrMigrationChecksum before anything runs.
+//
+// The checksums are verified by replaying the Up of the applied migrations, in
+// version order, on a dry-run bridge with an empty storage; a replay failing there
+// fails Migrate, so Up must not depend on schemas created outside the migrations.
+func (dbb *DBBridge) Migrate(targetVersion int64) error {
+	if dbb.trx != nil {
+		return fmt.Errorf("%w: Migrate cannot run inside a transaction", ErrInvalidMigration)
+	}
+
+	migrations, err := sortedMigrations(dbb.migrations)
+	if err != nil {
+		return err
+	}
+
+	var checksums map[int64]string
+	for done := false; !done; {
+		err := dbb.Transaction(func(tx *DBBridge) error {
+			if _, err := tx.execQuery(migrationLockSQL, MigrationsTable); err != nil {
+				return err
+			}
+			if _, err := tx.execQuery(createMigrationsTableSQL); err != nil {
+				return err
+			}
+
+			applied, err := tx.appliedChecksums()
+			if err != nil {
+				return err
+			}
+			// the versions applied later by this call were just recorded as computed
+			if checksums == nil {
+				if checksums, err = dbb.migrationChecksums(migrations, applied); err != nil {
+					return err
+				}
+			}
+			for version, sum := range applied {
+				if expected, ok := checksums[version]; ok && expected != sum {
+					return fmt.Errorf("%w: version %d", ErrMigrationChecksum, version)
+				}
+			}
+
+			m, err := revertibleMigration(migrations, applied, targetVersion)
+			if err != nil {
+				return err
+			}
+			if m != nil {
+				return tx.revertMigration(m)
+			}
+
+			if m := pendingMigration(migrations, applied, targetVersion); m != nil {
+				return tx.applyMigration(m)
+			}
+
+			done = true
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AppliedMigrations lists the migrations applied by Migrate, by version; none when
+// the table was not created yet.
+func (dbb *DBBridge) AppliedMigrations() ([]*AppliedMigration, error) {
+	return dbb.migrationRows(`WHERE version IS NOT NULL ORDER BY version`)
+}
+
+// SchemaHistory lists every row of ndb_migrations in the order it was written:
+// applied migrations and the schema operations run outside Migrate.
+func (dbb *DBBridge) SchemaHistory() ([]*AppliedMigration, error) {
+	return dbb.migrationRows(`ORDER BY id`)
+}
+
+func (dbb *DBBridge) migrationRows(filter string) ([]*AppliedMigration, error) {
+	var exists bool
+	err := dbb.catalogRows(`SELECT to_regclass(quote_ident($1)) IS NOT NULL`, []any{MigrationsTable}, func(rows *sql.Rows) error {
+		return rows.Scan(&exists)
+	})
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	var out []*AppliedMigration
+	err = dbb.catalogRows(`SELECT version, name, checksum, alter_fields, applied_at FROM "`+MigrationsTable+`" `+filter, nil, func(rows *sql.Rows) error {
+		var (
+			m       AppliedMigration
+			version sql.NullInt64
+			changes []byte
+		)
+		if err := rows.Scan(&version, &m.Name, &m.Checksum, &changes, &m.AppliedAt); err != nil {
+			return err
+		}
+		m.Version = version.Int64
+		if err := json.Unmarshal(changes, &m.Changes); err != nil {
+			return err
+		}
+
+		out = append(out, &m)
+		return nil
+	})
+
+	return out, err
+}
+
+func (dbb *DBBridge) applyMigration(m *Migration) error {
+	dbb.migration = &migrationLog{changes: []*MigrationChange{}}
+	if err := m.Up(dbb); err != nil {
+		return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+	}
+
+	changes, err := json.Marshal(dbb.migration.changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbb.execQuery(`INSERT INTO "`+MigrationsTable+`" (version, name, checksum, alter_fields) VALUES ($1, $2, $3, $4)`,
+		m.Version, m.Name, dbb.migration.checksum(), string(changes))
+	return err
+}
+
+func (dbb *DBBridge) revertMigration(m *Migration) error {
+	dbb.migration = &migrationLog{}
+	if err := m.Down(dbb); err != nil {
+		return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+	}
+
+	_, err := dbb.execQuery(`DELETE FROM "`+MigrationsTable+`" WHERE version = $1`, m.Version)
+	return err
+}
+
+// appliedChecksums maps each applied version to its recorded checksum.
+func (dbb *DBBridge) appliedChecksums() (map[int64]string, error) {
+	applied := map[int64]string{}
+	err := dbb.catalogRows(`SELECT version, checksum FROM "`+MigrationsTable+`" WHERE version IS NOT NULL`, nil, func(rows *sql.Rows) error {
+		var (
+			version int64
+			sum     string
+		)
+		if err := rows.Scan(&version, &sum); err != nil {
+			return err
+		}
+		applied[version] = sum
+		return nil
+	})
+
+	return applied, err
+}
+
+// migrationChecksums replays the Up steps of the applied migrations, in order, on
+// a dry-run bridge backed by an empty temporary storage, as on a fresh database,
+// and returns the checksum of the DDL each one generates. The schemas Up hands to
+// the replay are saved as copies, so the caller's values get no storage metadata.
+func (dbb *DBBridge) migrationChecksums(migrations []*Migration, applied map[int64]string) (map[int64]string, error) {
+	checksums := map[int64]string{}
+	if len(applied) == 0 {
+		return checksums, nil
+	}
+
+	folder, err := os.MkdirTemp("", "ndb-migrations-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(folder)
+
+	storage, err := nstore.New[*Schema](folder)
+	if err != nil {
+		return nil, err
+	}
+
+	db, _ := NewDryRunDB()
+	defer db.Close()
+	replay := NewBridge(&NBridge{DB: db, SchemaPrefix: dbb.schemaPrefix, SchemaStorage: storage})
+
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+
+		log := &migrationLog{replay: true}
+		err := replay.TransactionWith(&TxOptions{MaxRetries: NoTxRetries}, func(tx *DBBridge) error {
+			tx.migration = log
+			return m.Up(tx)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("migration %d %s: checksum replay: %w", m.Version, m.Name, err)
+		}
+		checksums[m.Version] = log.checksum()
+	}
+
+	return checksums, nil
+}
+
+// savedSchema is the value a schema operation writes to the storage: s itself,
+// or a copy while replaying a migration for its checksum.
+func (dbb *DBBridge) savedSchema(s *Schema) *Schema {
+	if dbb.migration == nil || !dbb.migration.replay {
+		return s
+	}
+
+	c := Ptr(*s)
+	c.Metadata = nil
+	return c
+}
+
+func (l *migrationLog) checksum() string {
+	sum := sha256.Sum256([]byte(strings.Join(l.ddl, ";\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordChange adds a schema operation to the migration being applied. Outside
+// Migrate it writes its own unversioned row, in the transaction of the operation.
+func (dbb *DBBridge) recordChange(ddl string, change *MigrationChange) error {
+	if dbb.migration != nil {
+		dbb.migration.ddl = append(dbb.migration.ddl, ddl)
+		dbb.migration.changes = append(dbb.migration.changes, change)
+		return nil
+	}
+
+	if _, err := dbb.execQuery(migrationLockSQL, MigrationsTable); err != nil {
+		return err
+	}
+	if _, err := dbb.execQuery(createMigrationsTableSQL); err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal([]*MigrationChange{change})
+	if err != nil {
+		return err
+	}
+
+	log := &migrationLog{ddl: []string{ddl}}
+	_, err = dbb.execQuery(`INSERT INTO "`+MigrationsTable+`" (name, checksum, alter_fields) VALUES ($1, $2, $3)`,
+		change.Action+" "+change.Schema, log.checksum(), string(changes))
+	return err
+}
+
+// recordDiff records the steps run by Apply as one change.
+func (dbb *DBBridge) recordDiff(diff *SchemaDiff) error {
+	if diff.Empty() {
+		return nil
+	}
+
+	ddl := make([]string, len(diff.Steps))
+	for i, step := range diff.Steps {
+		ddl[i] = step.SQL
+	}
+
+	change := &MigrationChange{Action: "alter", Schema: diff.Desired.PName, Fields: diff.Fields}
+	if diff.Create {
+		change.Action, change.Fields = "create", nil
+	}
+	return dbb.recordChange(strings.Join(ddl, ";\n"), change)
+}
+
+func sortedMigrations(migrations []*Migration) ([]*Migration, error) {
+	sorted := slices.Clone(migrations)
+	slices.SortFunc(sorted, func(a, b *Migration) int { return cmp.Compare(a.Version, b.Version) })
+
+	for i, m := range sorted {
+		switch {
+		case m.Version <= 0 || m.Version == LatestMigration:
+			return nil, fmt.Errorf("%w: version %d out of range", ErrInvalidMigration, m.Version)
+		case m.Up == nil:
+			return nil, fmt.Errorf("%w: version %d has no Up step", ErrInvalidMigration, m.Version)
+		case i > 0 && sorted[i-1].Version == m.Version:
+			return nil, fmt.Errorf("%w: version %d registered twice", ErrInvalidMigration, m.Version)
+		}
+	}
+
+	return sorted, nil
+}
+
+// pendingMigration returns the lowest registered migration up to target not applied yet.
+func pendingMigration(migrations []*Migration, applied map[int64]string, target int64) *Migration {
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok && m.Version <= target {
+			return m
+		}
+	}
+	return nil
+}
+
+// revertibleMigration returns the highest applied migration above target.
+func revertibleMigration(migrations []*Migration, applied map[int64]string, target int64) (*Migration, error) {
+	var highest int64
+	for version := range applied {
+		if version > target && version > highest {
+			highest = version
+		}
+	}
+	if highest == 0 {
+		return nil, nil
+	}
+
+	i, ok := slices.BinarySearchFunc(migrations, highest, func(m *Migration, v int64) int { return cmp.Compare(m.Version, v) })
+	if !ok {
+		return nil, fmt.Errorf("%w: version %d", ErrUnknownMigration, highest)
+	}
+	if migrations[i].Down == nil {
+		return nil, fmt.Errorf("%w: version %d", ErrIrreversibleMigration, highest)
+	}
+	return migrations[i], nil
+}
@@ -1,8 +1,12 @@
 package ndb
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"time"
 
 	"github.com/nitsugaro/go-nstore"
 	goutils "github.com/nitsugaro/go-utils"
@@ -12,6 +16,9 @@ type DBFuncs interface {
 	Query(query string, args ...any) (*sql.Rows, error)
 	Exec(query string, args ...any) (sql.Result, error)
 	Begin() (*sql.Tx, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
 
 type M = map[string]any
@@ -20,9 +27,21 @@ type DBBridge struct {
 	schemaPrefix  string
 	db            *sql.DB
 	trx           *sql.Tx
+	ctx           context.Context
+	depth         int
+	hooks         *txHooks
+	cursorSecret  []byte
+	router        *replicaRouter
+	stmts         *stmtCache
+	shapes        *shapeCache
+	reading       bool
+	query         *Query
 	prevValidate  []QueryMiddleware
 	postValidate  []QueryMiddleware
+	afterExecute  []ExecuteHook
 	schemaStorage *nstore.NStorage[*Schema]
+	migrations    []*Migration
+	migration     *migrationLog
 }
 
 func (dbb *DBBridge) GetSchemas(query ...nstore.ConditionalFunc[*Schema]) []*Schema {
@@ -44,14 +63,18 @@ func (dbb *DBBridge) GetSchemaByName(name string) (*Schema, bool) {
 }
 
 func (dbb *DBBridge) CreateSchema(schema *Schema) error {
-	_, err := dbb.ExecuteQuery(dbb.generateCreateSchemaSQL(schema))
-	if err != nil {
-		return err
-	}
+	sql := dbb.generateCreateSchemaSQL(schema)
 
-	dbb.schemaStorage.Save(schema)
+	return dbb.schemaTransaction(schema.PName, schema, func(tx *DBBridge) error {
+		if _, err := tx.ExecuteQuery(sql); err != nil {
+			return err
+		}
+		if err := tx.recordChange(sql, &MigrationChange{Action: "create", Schema: schema.PName}); err != nil {
+			return err
+		}
 
-	return nil
+		return dbb.schemaStorage.Save(tx.savedSchema(schema))
+	})
 }
 
 func (dbb *DBBridge) ModifySchema(schemaName string, fields []*AlterField) error {
@@ -61,11 +84,16 @@ func (dbb *DBBridge) ModifySchema(schemaName string, fields []*AlterField) error
 		return err
 	}
 
-	if _, err := dbb.ExecuteQuery(sql); err != nil {
-		return err
-	}
+	return dbb.schemaTransaction(schemaName, newSchema, func(tx *DBBridge) error {
+		if _, err := tx.ExecuteQuery(sql); err != nil {
+			return err
+		}
+		if err := tx.recordChange(sql, &MigrationChange{Action: "alter", Schema: schemaName, Fields: fields}); err != nil {
+			return err
+		}
 
-	return dbb.schemaStorage.Save(newSchema)
+		return dbb.schemaStorage.Save(newSchema)
+	})
 }
 
 func (dbb *DBBridge) DeleteSchema(name string) error {
@@ -74,35 +102,149 @@ func (dbb *DBBridge) DeleteSchema(name string) error {
 		return fmt.Errorf("schema '%s' not found", name)
 	}
 
-	_, err := dbb.ExecuteQuery(dbb.generateDropSchemaSql(name))
+	sql := dbb.generateDropSchemaSql(name)
+
+	return dbb.schemaTransaction(name, nil, func(tx *DBBridge) error {
+		if _, err := tx.ExecuteQuery(sql); err != nil {
+			return err
+		}
+		if err := tx.recordChange(sql, &MigrationChange{Action: "drop", Schema: name}); err != nil {
+			return err
+		}
+
+		return dbb.schemaStorage.Delete(schema.ID)
+	})
+}
+
+// schemaTransaction runs change, the DDL of a schema operation then its storage
+// write of written (nil for a delete), in a transaction or a savepoint. The write
+// comes last, before commit; if the commit or an enclosing transaction fails
+// afterwards, the stored schema name is restored to its previous state.
+func (dbb *DBBridge) schemaTransaction(name string, written *Schema, change func(tx *DBBridge) error) error {
+	restore := dbb.storageRestore(name, written)
+
+	var restoreErr error
+	err := dbb.Transaction(func(tx *DBBridge) error {
+		// registered first: a failed write may have reached the storage cache already
+		tx.hooks.compensations = append(tx.hooks.compensations, func() { restoreErr = restore() })
+		return change(tx)
+	})
 	if err != nil {
-		return err
+		return errors.Join(err, restoreErr)
 	}
+	dbb.InvalidateStatements()
 
-	return dbb.schemaStorage.Delete(schema.ID)
+	return nil
+}
+
+// storageRestore snapshots the stored schema name and returns the compensation
+// of a write on it: written is removed unless it replaced the snapshot in place,
+// and the snapshot, if any, is saved back.
+func (dbb *DBBridge) storageRestore(name string, written *Schema) func() error {
+	var snapshot *Schema
+	if stored, ok := dbb.GetSchemaByName(name); ok {
+		// Save bumps the metadata in place, and the written schema may share it
+		snapshot = Ptr(*stored)
+		if stored.Metadata != nil {
+			snapshot.Metadata = Ptr(*stored.Metadata)
+		}
+	}
+
+	return func() error {
+		if written != nil && written.Metadata != nil && (snapshot == nil || snapshot.Metadata == nil || written.ID != snapshot.ID) {
+			if err := dbb.schemaStorage.Delete(written.ID); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+		if snapshot == nil {
+			return nil
+		}
+		return dbb.schemaStorage.Save(snapshot)
+	}
 }
 
 func (dbb *DBBridge) GetSchemaPrefix() string {
 	return dbb.schemaPrefix
 }
 
+// WithContext returns a shallow copy of the bridge whose queries, execs and
+// transactions run bound to ctx, so cancellation and deadlines stop the SQL.
+func (dbb *DBBridge) WithContext(ctx context.Context) *DBBridge {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	b := *dbb
+	b.ctx = ctx
+	return &b
+}
+
+// Context returns the context bound to the bridge, context.Background() by default.
+func (dbb *DBBridge) Context() context.Context {
+	if dbb.ctx == nil {
+		return context.Background()
+	}
+	return dbb.ctx
+}
+
 type NBridge struct {
-	DB                      *sql.DB
-	SchemaPrefix            string
-	SchemaStorage           *nstore.NStorage[*Schema]
+	DB            *sql.DB
+	SchemaPrefix  string
+	SchemaStorage *nstore.NStorage[*Schema]
+	// CursorSecret signs keyset cursors; defaults to ndb.cursor_secret. Without either
+	// ReadKeyset fails with ErrNoCursorSecret
+	CursorSecret []byte
+	// Replicas receive the READ queries; DB stays the primary for writes and transactions
+	Replicas      []*sql.DB
+	ReplicaPolicy ReplicaPolicy
+	// ReadYourWritesWindow sends reads to the primary for this long after a write
+	ReadYourWritesWindow time.Duration
+	// StatementCacheSize enables an LRU of prepared statements keyed by the generated SQL
+	StatementCacheSize int
+	// QueryShapeCacheSize enables an LRU of read SQL keyed by the query structure
+	QueryShapeCacheSize     int
+	stmts                   *stmtCache
+	shapes                  *shapeCache
+	router                  *replicaRouter
 	trx                     *sql.Tx
+	ctx                     context.Context
+	depth                   int
+	hooks                   *txHooks
 	prevValidatemiddlewares []QueryMiddleware
 	postValidatemiddlewares []QueryMiddleware
+	afterExecuteHooks       []ExecuteHook
+	migration               *migrationLog
 }
 
 func NewBridge(nbrigde *NBridge) *DBBridge {
 	brigde := &DBBridge{
 		db:            nbrigde.DB,
 		trx:           nbrigde.trx,
+		ctx:           nbrigde.ctx,
+		depth:         nbrigde.depth,
+		hooks:         nbrigde.hooks,
 		schemaPrefix:  nbrigde.SchemaPrefix,
 		schemaStorage: nbrigde.SchemaStorage,
+		cursorSecret:  nbrigde.CursorSecret,
+		router:        nbrigde.router,
+		stmts:         nbrigde.stmts,
+		shapes:        nbrigde.shapes,
 		prevValidate:  nbrigde.prevValidatemiddlewares,
 		postValidate:  nbrigde.postValidatemiddlewares,
+		afterExecute:  nbrigde.afterExecuteHooks,
+		migration:     nbrigde.migration,
+	}
+
+	if brigde.router == nil && len(nbrigde.Replicas) != 0 {
+		brigde.router = &replicaRouter{replicas: nbrigde.Replicas, policy: nbrigde.ReplicaPolicy, window: nbrigde.ReadYourWritesWindow}
+	}
+
+	if brigde.stmts == nil && nbrigde.StatementCacheSize > 0 {
+		brigde.stmts = newStmtCache(nbrigde.StatementCacheSize, nbrigde.DB)
+	}
+
+	if brigde.shapes == nil && nbrigde.QueryShapeCacheSize > 0 {
+		brigde.shapes = newShapeCache(nbrigde.QueryShapeCacheSize)
 	}
 
 	if brigde.prevValidate == nil {
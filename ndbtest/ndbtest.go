@@ -0,0 +1,152 @@
+// Package ndbtest snapshots the SQL the ndb builders generate into golden files.
+//
+//	func TestQueries(t *testing.T) {
+//		bridge, _ := ndb.NewDryRunBridge(schemaStorage)
+//		ndbtest.Run(t, bridge, []ndbtest.Case{
+//			{Name: "active_users", Query: ndb.NewReadQuery("users").Where(ndb.M{"status": "active"})},
+//		})
+//	}
+//
+// Run the tests with -update to write testdata/golden/<case>.golden instead of
+// comparing against it.
+package ndbtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+var update = flag.Bool("update", false, "rewrite the ndbtest golden files")
+
+// Dir is the folder holding the golden files, relative to the test package.
+var Dir = filepath.Join("testdata", "golden")
+
+type Case struct {
+	Name  string
+	Query *ndb.Query
+}
+
+// JSONCase is a case defined in JSON: the query uses the json tags of ndb.Query.
+type JSONCase struct {
+	Name  string          `json:"name"`
+	Type  ndb.QueryType   `json:"type"`
+	Query json.RawMessage `json:"query"`
+}
+
+// LoadCases reads a JSON array of JSONCase from path.
+func LoadCases(path string) ([]Case, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []JSONCase
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+
+	cases := make([]Case, 0, len(defs))
+	for _, def := range defs {
+		q, err := newQuery(def.Type)
+		if err != nil {
+			return nil, fmt.Errorf("case %s: %w", def.Name, err)
+		}
+
+		if err := json.Unmarshal(def.Query, q); err != nil {
+			return nil, fmt.Errorf("case %s: %w", def.Name, err)
+		}
+		cases = append(cases, Case{Name: def.Name, Query: q})
+	}
+
+	return cases, nil
+}
+
+func newQuery(typ ndb.QueryType) (*ndb.Query, error) {
+	switch typ {
+	case ndb.READ, "":
+		return ndb.NewReadQuery(""), nil
+	case ndb.CREATE:
+		return ndb.NewCreateQuery(""), nil
+	case ndb.UPDATE:
+		return ndb.NewUpdateQuery(""), nil
+	case ndb.DELETE:
+		return ndb.NewDeleteQuery(""), nil
+	}
+
+	return nil, ndb.ErrInvalidQueryType
+}
+
+// Run builds every case with bridge and compares its SQL and args with its golden
+// file, one subtest per case.
+func Run(t *testing.T, bridge *ndb.DBBridge, cases []Case) {
+	t.Helper()
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			query, args, err := bridge.BuildQuery(c.Query)
+			if err != nil {
+				t.Fatalf("build_error: %v", err)
+			}
+
+			AssertGolden(t, c.Name, query, args)
+		})
+	}
+}
+
+// AssertGolden compares query and args with Dir/<name>.golden, or writes the file
+// when the tests run with -update.
+func AssertGolden(t testing.TB, name string, query string, args []any) {
+	t.Helper()
+
+	actual, err := Snapshot(query, args)
+	if err != nil {
+		t.Fatalf("snapshot_error: %v", err)
+	}
+
+	path := filepath.Join(Dir, name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden_dir_error: %v", err)
+		}
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatalf("golden_write_error: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden_read_error: %v (run with -update to create it)", err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("golden_mismatch %s\n--- expected\n%s--- actual\n%s", path, expected, actual)
+	}
+}
+
+// Snapshot renders the golden file content: the SQL, then its args as JSON.
+func Snapshot(query string, args []any) ([]byte, error) {
+	if args == nil {
+		args = []any{}
+	}
+
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteString(query)
+	out.WriteString("\n-- args: ")
+	out.Write(encoded)
+	out.WriteByte('\n')
+
+	return out.Bytes(), nil
+}
@@ -19,15 +19,23 @@ type Query struct {
 	typ              QueryType
 	asRestResource   bool
 	asRestCollection bool
+	primary          bool
+	unbounded        bool
 
-	PFields  []*SQLField `json:"fields,omitempty"`
-	PWhere   []M         `json:"where,omitempty"`
-	PLimit   int         `json:"limit,omitempty"`
-	POffset  int         `json:"offset,omitempty"`
-	PGroupBy []*SQLField `json:"group_by,omitempty"`
-	POrderBy []*SQLField `json:"order_by,omitempty"`
-	PJoins   []*Join     `json:"joins,omitempty"`
-	RPayload M           `json:"payload,omitempty"`
+	PFields   []*SQLField `json:"fields,omitempty"`
+	PWhere    []M         `json:"where,omitempty"`
+	PLimit    int         `json:"limit,omitempty"`
+	POffset   int         `json:"offset,omitempty"`
+	PGroupBy  []*SQLField `json:"group_by,omitempty"`
+	POrderBy  []*SQLField `json:"order_by,omitempty"`
+	PJoins    []*Join     `json:"joins,omitempty"`
+	RPayload  M           `json:"payload,omitempty"`
+	RPayloads []M         `json:"payloads,omitempty"`
+
+	POnConflict *Conflict `json:"on_conflict,omitempty"`
+
+	PCursor    string `json:"cursor,omitempty"`
+	PCursorDir string `json:"cursor_dir,omitempty"`
 
 	subQuery *SubQuery
 }
@@ -96,16 +104,22 @@ func NewRESTDeleteQueryResouce(table string) *Query {
 
 func (q *Query) Clone() *Query {
 	return &Query{
-		typ:      q.typ,
-		PFields:  q.PFields,
-		PWhere:   q.PWhere,
-		PLimit:   q.PLimit,
-		POffset:  q.POffset,
-		PGroupBy: q.PGroupBy,
-		POrderBy: q.POrderBy,
-		PJoins:   q.PJoins,
-		RPayload: q.RPayload,
-		subQuery: q.subQuery,
+		typ:         q.typ,
+		primary:     q.primary,
+		unbounded:   q.unbounded,
+		PFields:     q.PFields,
+		PWhere:      q.PWhere,
+		PLimit:      q.PLimit,
+		POffset:     q.POffset,
+		PGroupBy:    q.PGroupBy,
+		POrderBy:    q.POrderBy,
+		PJoins:      q.PJoins,
+		RPayload:    q.RPayload,
+		RPayloads:   q.RPayloads,
+		POnConflict: q.POnConflict,
+		PCursor:     q.PCursor,
+		PCursorDir:  q.PCursorDir,
+		subQuery:    q.subQuery,
 	}
 }
 
@@ -3,6 +3,7 @@ package ndb
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -12,6 +13,16 @@ func writeDollarPos(b *strings.Builder, pos int) {
 	b.WriteString(strconv.Itoa(pos))
 }
 
+// sortedKeys fills buf with the keys of m in order; the builders walk maps (payloads,
+// where groups) sorted so the same query always yields the same SQL and args.
+func sortedKeys(m M, buf []string) []string {
+	for k := range m {
+		buf = append(buf, k)
+	}
+	slices.Sort(buf)
+	return buf
+}
+
 func (dbb *DBBridge) buildConditionClauseB(b *strings.Builder, clauseArr []M, startPos int, prefix string) ([]any, int, error) {
 	if len(clauseArr) == 0 {
 		return nil, startPos, nil
@@ -56,7 +67,8 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 		first = false
 	}
 
-	for key, val := range group {
+	for _, key := range sortedKeys(group, make([]string, 0, 8)) {
+		val := group[key]
 		if key == "not" {
 			notGroup, ok := val.(M)
 			if !ok {
@@ -78,11 +90,17 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 		switch v := val.(type) {
 		case M:
-			for op, val2 := range v {
+			sKey, err := FormatSQLField(dbb.schemaPrefix, key)
+			if err != nil {
+				return pos, err
+			}
+
+			for _, op := range sortedKeys(v, make([]string, 0, 4)) {
+				val2 := v[op]
 				switch strings.ToLower(op) {
 				case "gt":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" > ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -90,7 +108,7 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 				case "gte":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" >= ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -98,7 +116,7 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 				case "lt":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" < ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -106,7 +124,7 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 				case "lte":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" <= ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -114,7 +132,7 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 				case "ne":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" != ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -122,7 +140,7 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 				case "like":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" LIKE ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -130,7 +148,7 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 
 				case "ilike", "i_like":
 					addSep()
-					b.WriteString(key)
+					b.WriteString(sKey)
 					b.WriteString(" ILIKE ")
 					writeDollarPos(b, pos)
 					*args = append(*args, val2)
@@ -142,11 +160,6 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 						return pos, fmt.Errorf("invalid IN clause for %s", key)
 					}
 
-					sKey, err := FormatSQLField(dbb.schemaPrefix, key)
-					if err != nil {
-						return pos, err
-					}
-
 					addSep()
 					b.WriteString(sKey)
 					b.WriteString(" IN (")
@@ -167,11 +180,6 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 						return pos, fmt.Errorf("invalid NOT IN clause for %s", key)
 					}
 
-					sKey, err := FormatSQLField(dbb.schemaPrefix, key)
-					if err != nil {
-						return pos, err
-					}
-
 					addSep()
 					b.WriteString(sKey)
 					b.WriteString(" NOT IN (")
@@ -192,11 +200,6 @@ func (dbb *DBBridge) parseAndGroupToBuilder(group M, startPos int, b *strings.Bu
 						return pos, fmt.Errorf("isnull operator expects boolean")
 					}
 
-					sKey, err := FormatSQLField(dbb.schemaPrefix, key)
-					if err != nil {
-						return pos, err
-					}
-
 					addSep()
 					b.WriteString(sKey)
 					if isNull {
@@ -0,0 +1,172 @@
+package ndb
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type ConflictAction string
+
+const (
+	DO_NOTHING ConflictAction = "NOTHING"
+	DO_UPDATE  ConflictAction = "UPDATE"
+)
+
+type Conflict struct {
+	PColumns []string       `json:"columns"`
+	PAction  ConflictAction `json:"action"`
+	PUpdate  []string       `json:"update,omitempty"`
+	PWhere   []M            `json:"where,omitempty"`
+
+	q *Query
+}
+
+// OnConflict starts an ON CONFLICT (columns) clause for a create query. The columns
+// must match a primary key or unique index declared on the stored schema.
+func (q *Query) OnConflict(columns ...string) *Conflict {
+	q.POnConflict = &Conflict{PColumns: columns, PAction: DO_NOTHING, q: q}
+	return q.POnConflict
+}
+
+func (c *Conflict) DoNothing() *Conflict {
+	c.PAction = DO_NOTHING
+	c.PUpdate = nil
+	return c
+}
+
+// DoUpdate sets each column to its EXCLUDED value when the insert conflicts.
+func (c *Conflict) DoUpdate(columns ...string) *Conflict {
+	c.PAction = DO_UPDATE
+	c.PUpdate = columns
+	return c
+}
+
+// Where restricts the DO UPDATE to the existing rows matching the conditions.
+func (c *Conflict) Where(conditions ...M) *Conflict {
+	c.PWhere = conditions
+	return c
+}
+
+func (c *Conflict) DoneConflict() *Query {
+	return c.q
+}
+
+func (q *Query) GetOnConflict() *Conflict {
+	return q.POnConflict
+}
+
+func (dbb *DBBridge) validateConflict(schemaName string, c *Conflict) error {
+	if len(c.PColumns) == 0 {
+		return fmt.Errorf("%w: conflict columns are required", ErrInvalidConflict)
+	}
+
+	if c.PAction != DO_NOTHING && c.PAction != DO_UPDATE {
+		return fmt.Errorf("%w: unsupported action %s", ErrInvalidConflict, c.PAction)
+	}
+
+	if c.PAction == DO_UPDATE && len(c.PUpdate) == 0 {
+		return fmt.Errorf("%w: DO UPDATE requires columns", ErrInvalidConflict)
+	}
+
+	for _, col := range append(slices.Clone(c.PColumns), c.PUpdate...) {
+		if err := IsSQLName(col); err != nil {
+			return err
+		}
+	}
+
+	if dbb.schemaStorage == nil {
+		return nil
+	}
+
+	schema, ok := dbb.GetSchemaByName(schemaName)
+	if !ok {
+		return ErrSchemaKeyNotFound
+	}
+
+	for _, col := range append(slices.Clone(c.PColumns), c.PUpdate...) {
+		if schema.GetField(col) == nil {
+			return fmt.Errorf("%w: field '%s' not found in schema '%s'", ErrInvalidConflict, col, schemaName)
+		}
+	}
+
+	if !slices.ContainsFunc(schema.uniqueKeys(), func(key []string) bool { return sameColumns(key, c.PColumns) }) {
+		return fmt.Errorf("%w: (%s) is not a unique key of schema '%s'", ErrInvalidConflict, strings.Join(c.PColumns, ","), schemaName)
+	}
+
+	return nil
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, col := range a {
+		if !slices.Contains(b, col) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// writeConflictClause appends the ON CONFLICT clause (if any) and returns the args of its WHERE.
+func (dbb *DBBridge) writeConflictClause(b *strings.Builder, createQuery *Query, pos int) ([]any, error) {
+	c := createQuery.POnConflict
+	if c == nil {
+		return nil, nil
+	}
+
+	if err := dbb.validateConflict(createQuery.PSchema, c); err != nil {
+		return nil, err
+	}
+
+	b.WriteString(" ON CONFLICT (")
+	b.WriteString(strings.Join(c.PColumns, ","))
+	b.WriteString(") DO ")
+	b.WriteString(string(c.PAction))
+
+	if c.PAction == DO_NOTHING {
+		return nil, nil
+	}
+
+	b.WriteString(" SET ")
+	for i, col := range c.PUpdate {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(col)
+		b.WriteString(" = EXCLUDED.")
+		b.WriteString(col)
+	}
+
+	args, _, err := dbb.buildConditionClauseB(b, qualifyConditions(createQuery.PSchema, c.PWhere), pos, "WHERE")
+	return args, err
+}
+
+// qualifyConditions prefixes the unqualified columns of conditions with schema:
+// the target row and EXCLUDED are both in scope in DO UPDATE ... WHERE.
+func qualifyConditions(schema string, conditions []M) []M {
+	out := make([]M, len(conditions))
+	for i, group := range conditions {
+		out[i] = qualifyGroup(schema, group)
+	}
+	return out
+}
+
+func qualifyGroup(schema string, group M) M {
+	out := make(M, len(group))
+	for key, val := range group {
+		switch {
+		case key == "not":
+			if not, ok := val.(M); ok {
+				val = qualifyGroup(schema, not)
+			}
+		case !strings.Contains(key, "."):
+			key = schema + "." + key
+		}
+		out[key] = val
+	}
+	return out
+}
@@ -0,0 +1,233 @@
+package ndb
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// PostgreSQL wire protocol limit of bind parameters per statement
+const maxQueryParams = 65535
+
+func (dbb *DBBridge) validateCreateRow(createQuery *Query, row M) error {
+	prev := createQuery.RPayload
+	createQuery.RPayload = row
+	defer func() { createQuery.RPayload = prev }()
+
+	if err := dbb.runPrevValidateMiddlewares(createQuery); err != nil {
+		return err
+	}
+
+	if err := dbb.ValidateSchema(createQuery.PSchema, CREATE, row); err != nil {
+		return err
+	}
+
+	if len(row) == 0 {
+		return ErrEmptyCreateData
+	}
+
+	return dbb.runPostValidateMiddlewares(createQuery)
+}
+
+// BuildCreateManyQuery builds multi-row INSERT statements for the query payloads, chunked
+// so no statement exceeds the bind parameter limit. Columns missing in a row are sent as DEFAULT.
+func (dbb *DBBridge) BuildCreateManyQuery(createQuery *Query) ([]string, [][]any, error) {
+	if createQuery.typ != CREATE {
+		return nil, nil, ErrInvalidQueryType
+	}
+
+	if len(createQuery.RPayloads) == 0 {
+		return nil, nil, ErrEmptyCreateData
+	}
+
+	table, err := createQuery.GetSchema(dbb)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selectFields, err := createQuery.GetFormattedFields(dbb.schemaPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := map[string]bool{}
+	var keys []string
+	for _, row := range createQuery.RPayloads {
+		if err := dbb.validateCreateRow(createQuery, row); err != nil {
+			return nil, nil, err
+		}
+
+		for k := range row {
+			if seen[k] {
+				continue
+			}
+
+			if err := IsSQLName(k); err != nil {
+				return nil, nil, err
+			}
+
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	// every chunk repeats the ON CONFLICT ... WHERE args after its rows
+	conflictArgs, err := dbb.writeConflictClause(&strings.Builder{}, createQuery, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	rowsPerChunk := max((maxQueryParams-len(conflictArgs))/len(keys), 1)
+
+	var (
+		queries []string
+		chunks  [][]any
+	)
+
+	for start := 0; start < len(createQuery.RPayloads); start += rowsPerChunk {
+		end := min(start+rowsPerChunk, len(createQuery.RPayloads))
+
+		var (
+			query = &strings.Builder{}
+			args  []any
+			pos   = 1
+		)
+
+		query.WriteString("INSERT INTO ")
+		query.WriteString(table)
+		query.WriteString(" (")
+		query.WriteString(strings.Join(keys, ","))
+		query.WriteString(") VALUES ")
+
+		for i, row := range createQuery.RPayloads[start:end] {
+			if i > 0 {
+				query.WriteByte(',')
+			}
+			query.WriteByte('(')
+
+			for j, k := range keys {
+				if j > 0 {
+					query.WriteByte(',')
+				}
+
+				v, ok := row[k]
+				if !ok {
+					query.WriteString("DEFAULT")
+					continue
+				}
+
+				writeDollarPos(query, pos)
+				args = append(args, v)
+				pos++
+			}
+
+			query.WriteByte(')')
+		}
+
+		conflictArgs, err := dbb.writeConflictClause(query, createQuery, pos)
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, conflictArgs...)
+
+		query.WriteString(" RETURNING ")
+		query.WriteString(strings.Join(selectFields, ","))
+
+		queryStr := query.String()
+		if logEnabled {
+			color.Yellow(queryStr)
+		}
+
+		queries = append(queries, queryStr)
+		chunks = append(chunks, args)
+	}
+
+	return queries, chunks, nil
+}
+
+// runChunks executes every chunk atomically: chunks after the first one would
+// otherwise leave partial inserts behind when a later chunk fails.
+func (dbb *DBBridge) runChunks(queries []string, fn func(bridge *DBBridge, i int) error) error {
+	if len(queries) == 1 || dbb.trx != nil {
+		for i := range queries {
+			if err := fn(dbb, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// fn collects the RETURNING rows of each chunk: a retry would collect them twice
+	return dbb.TransactionWith(&TxOptions{MaxRetries: NoTxRetries}, func(tx *DBBridge) error {
+		for i := range queries {
+			if err := fn(tx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CreateMany inserts every payload set with Payloads and returns the RETURNING rows.
+// Neither their order nor a row per payload is guaranteed: Postgres does not order
+// the RETURNING rows of a multi-row VALUES, and ON CONFLICT DO NOTHING (or a DO
+// UPDATE filtered by Where) leaves out the skipped rows. Match rows on a key.
+func (dbb *DBBridge) CreateMany(createQuery *Query) ([]M, error) {
+	queries, chunks, err := dbb.BuildCreateManyQuery(createQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]M, 0, len(createQuery.RPayloads))
+	err = dbb.runChunks(queries, func(bridge *DBBridge, i int) error {
+		rows, err := bridge.observed(createQuery).ExecuteQuery(queries[i], chunks[i]...)
+		if err != nil {
+			return err
+		}
+
+		result = append(result, rows...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CreateManyB is CreateMany decoding the RETURNING rows into v (a pointer to a slice),
+// with the same caveats on their order and count.
+func (dbb *DBBridge) CreateManyB(createQuery *Query, v any) error {
+	queries, chunks, err := dbb.BuildCreateManyQuery(createQuery)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('[')
+
+	err = dbb.runChunks(queries, func(bridge *DBBridge, i int) error {
+		b, err := bridge.observed(createQuery).ExecuteQueryBytes(queries[i], false, chunks[i]...)
+		if err != nil {
+			return err
+		}
+
+		if len(b) == 0 {
+			return nil
+		}
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		out.Write(b)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out.WriteByte(']')
+	return json.Unmarshal(out.Bytes(), v)
+}
@@ -49,14 +49,14 @@ func (dbb *DBBridge) BuildCreateQuery(createQuery *Query) (string, []any, error)
 		var placeholders []string
 		pos := 1
 
-		for k, v := range createQuery.RPayload {
+		for _, k := range sortedKeys(createQuery.RPayload, make([]string, 0, len(createQuery.RPayload))) {
 			if err := IsSQLName(k); err != nil {
 				return "", nil, err
 			}
 
 			keys = append(keys, k)
 			placeholders = append(placeholders, fmt.Sprintf("$%d", pos))
-			args = append(args, v)
+			args = append(args, createQuery.RPayload[k])
 			pos++
 		}
 
@@ -66,7 +66,15 @@ func (dbb *DBBridge) BuildCreateQuery(createQuery *Query) (string, []any, error)
 		query.WriteString(strings.Join(keys, ","))
 		query.WriteString(") VALUES (")
 		query.WriteString(strings.Join(placeholders, ","))
-		query.WriteString(") RETURNING ")
+		query.WriteByte(')')
+
+		conflictArgs, err := dbb.writeConflictClause(query, createQuery, pos)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, conflictArgs...)
+
+		query.WriteString(" RETURNING ")
 		query.WriteString(strings.Join(selectFields, ","))
 	} else {
 		if createQuery.subQuery == nil {
@@ -90,7 +98,15 @@ func (dbb *DBBridge) BuildCreateQuery(createQuery *Query) (string, []any, error)
 		query.WriteString(strings.Join(keys, ","))
 		query.WriteString(") (")
 		query.WriteString(subQuery)
-		query.WriteString(") RETURNING ")
+		query.WriteByte(')')
+
+		conflictArgs, err := dbb.writeConflictClause(query, createQuery, len(args)+1)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, conflictArgs...)
+
+		query.WriteString(" RETURNING ")
 		query.WriteString(strings.Join(selectFields, ","))
 	}
 
@@ -108,7 +124,7 @@ func (dbb *DBBridge) Create(createQuery *Query) ([]M, error) {
 		return nil, err
 	}
 
-	result, err := dbb.ExecuteQuery(query, args...)
+	result, err := dbb.observed(createQuery).ExecuteQuery(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +138,7 @@ func (dbb *DBBridge) CreateOne(createQuery *Query) (M, error) {
 		return nil, err
 	}
 
-	result, err := dbb.ExecuteQuery(query, args...)
+	result, err := dbb.observed(createQuery).ExecuteQuery(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +156,7 @@ func (dbb *DBBridge) createB(createQuery *Query, v any, arrayVal bool) error {
 		return err
 	}
 
-	bytes, err := dbb.ExecuteQueryBytes(query, arrayVal, args...)
+	bytes, err := dbb.observed(createQuery).ExecuteQueryBytes(query, arrayVal, args...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,307 @@
+package ndb
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+const (
+	CURSOR_AFTER  = "after"
+	CURSOR_BEFORE = "before"
+)
+
+var (
+	ErrInvalidCursor      = errors.New("invalid or tampered cursor")
+	ErrCursorWithoutOrder = errors.New("cursor pagination requires an order by clause")
+	ErrCursorField        = errors.New("cursor order field missing in result fields")
+	ErrNoCursorSecret     = errors.New("cursor secret not configured: set NBridge.CursorSecret or ndb.cursor_secret")
+)
+
+type cursorPayload struct {
+	Dir    string   `json:"d"`
+	Keys   []string `json:"k"`
+	Values []any    `json:"v"`
+}
+
+// CursorPage is a keyset page: Next/Prev are opaque tokens for the adjacent pages.
+type CursorPage struct {
+	Items []M    `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// After reads the rows that follow the cursor in the ORDER BY sequence.
+func (q *Query) After(cursor string) *Query {
+	q.PCursor = cursor
+	q.PCursorDir = CURSOR_AFTER
+	return q
+}
+
+// Before reads the rows that precede the cursor in the ORDER BY sequence.
+func (q *Query) Before(cursor string) *Query {
+	q.PCursor = cursor
+	q.PCursorDir = CURSOR_BEFORE
+	return q
+}
+
+// Cursor applies a token returned in CursorPage, in the direction it was issued for.
+func (q *Query) Cursor(cursor string) *Query {
+	q.PCursor = cursor
+	q.PCursorDir = ""
+	return q
+}
+
+// getCursorSecret fails without a configured secret: a per-process one would make
+// cursors unverifiable on other instances and after a restart.
+func (dbb *DBBridge) getCursorSecret() ([]byte, error) {
+	if len(dbb.cursorSecret) != 0 {
+		return dbb.cursorSecret, nil
+	}
+	if cursorSecret != "" {
+		return []byte(cursorSecret), nil
+	}
+	return nil, ErrNoCursorSecret
+}
+
+func (dbb *DBBridge) signCursor(p *cursorPayload) (string, error) {
+	secret, err := dbb.getCursorSecret()
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+
+	return base64.RawURLEncoding.EncodeToString(body) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func (dbb *DBBridge) decodeCursor(token string) (*cursorPayload, error) {
+	secret, err := dbb.getCursorSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	rawBody, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(rawBody)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(rawSig)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, ErrInvalidCursor
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	p := &cursorPayload{}
+	if err := dec.Decode(p); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	for i, v := range p.Values {
+		if n, ok := v.(json.Number); ok {
+			if iv, err := n.Int64(); err == nil {
+				p.Values[i] = iv
+			} else if fv, err := n.Float64(); err == nil {
+				p.Values[i] = fv
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// cursorOrder returns the ORDER BY fields (without the trailing direction) and the direction.
+func cursorOrder(q *Query) ([]*SQLField, string, error) {
+	if len(q.POrderBy) < 2 {
+		return nil, "", ErrCursorWithoutOrder
+	}
+
+	order := strings.ToUpper(q.POrderBy[len(q.POrderBy)-1].PName)
+	if order != "ASC" && order != "DESC" {
+		return nil, "", ErrCursorWithoutOrder
+	}
+
+	return q.POrderBy[:len(q.POrderBy)-1], order, nil
+}
+
+func cursorKeys(fields []*SQLField) []string {
+	keys := make([]string, len(fields))
+	for i, f := range fields {
+		keys[i] = f.PName
+	}
+	return keys
+}
+
+// resultKey is the column name an order field takes in the result rows.
+func resultKey(f *SQLField) string {
+	for _, op := range f.POperators {
+		if op.POp == AS && len(op.PArgs) > 0 {
+			return op.PArgs[0]
+		}
+	}
+
+	parts := strings.Split(f.PName, ".")
+	return parts[len(parts)-1]
+}
+
+// resolveCursor validates the query cursor and returns its payload with the effective direction.
+func (dbb *DBBridge) resolveCursor(q *Query) (*cursorPayload, error) {
+	fields, _, err := cursorOrder(q)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := dbb.decodeCursor(q.PCursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if !slices.Equal(p.Keys, cursorKeys(fields)) || len(p.Values) != len(fields) {
+		return nil, ErrInvalidCursor
+	}
+
+	if q.PCursorDir != "" {
+		p.Dir = q.PCursorDir
+	}
+	if p.Dir != CURSOR_AFTER && p.Dir != CURSOR_BEFORE {
+		return nil, ErrInvalidCursor
+	}
+
+	return p, nil
+}
+
+// writeCursorCondition writes "(a,b) > ($n,$n+1)" for the cursor. Reading before the
+// cursor flips the comparison; the caller also flips the ORDER BY direction.
+func (dbb *DBBridge) writeCursorCondition(b *strings.Builder, q *Query, p *cursorPayload, pos int) ([]any, error) {
+	fields, order, err := cursorOrder(q)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := ValidParseSqlFields(dbb.schemaPrefix, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	op := " > "
+	if (order == "DESC") != (p.Dir == CURSOR_BEFORE) {
+		op = " < "
+	}
+
+	b.WriteByte('(')
+	b.WriteString(strings.Join(cols, ","))
+	b.WriteByte(')')
+	b.WriteString(op)
+	b.WriteByte('(')
+	for i := range cols {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeDollarPos(b, pos+i)
+	}
+	b.WriteByte(')')
+
+	return p.Values, nil
+}
+
+func (dbb *DBBridge) cursorFromRow(fields []*SQLField, dir string, row M) (string, error) {
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		v, ok := row[resultKey(f)]
+		if !ok {
+			return "", ErrCursorField
+		}
+		values[i] = v
+	}
+
+	return dbb.signCursor(&cursorPayload{Dir: dir, Keys: cursorKeys(fields), Values: values})
+}
+
+// ReadKeyset reads one keyset page of the query. The query needs an ORDER BY whose fields
+// are part of the selected columns; the page size is its limit. Pass Next/Prev back through
+// After/Before/Cursor to move between pages.
+func (dbb *DBBridge) ReadKeyset(readQuery *Query) (*CursorPage, error) {
+	fields, _, err := cursorOrder(readQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := CURSOR_AFTER
+	if readQuery.PCursor != "" {
+		p, err := dbb.resolveCursor(readQuery)
+		if err != nil {
+			return nil, err
+		}
+		dir = p.Dir
+	}
+
+	limit := readQuery.GetLimit()
+	prevLimit := readQuery.PLimit
+	if limit > 0 {
+		readQuery.PLimit = limit + 1
+	}
+	defer func() { readQuery.PLimit = prevLimit }()
+
+	items, err := dbb.Read(readQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := limit > 0 && len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	if dir == CURSOR_BEFORE {
+		slices.Reverse(items)
+	}
+
+	page := &CursorPage{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	// moving forward there is a next page only if more rows came back; there is a previous
+	// one whenever we started from a cursor (and the other way around moving backward)
+	hasNext, hasPrev := hasMore, readQuery.PCursor != ""
+	if dir == CURSOR_BEFORE {
+		hasNext, hasPrev = readQuery.PCursor != "", hasMore
+	}
+
+	if hasNext {
+		if page.Next, err = dbb.cursorFromRow(fields, CURSOR_AFTER, items[len(items)-1]); err != nil {
+			return nil, err
+		}
+	}
+
+	if hasPrev {
+		if page.Prev, err = dbb.cursorFromRow(fields, CURSOR_BEFORE, items[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
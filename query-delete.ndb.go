@@ -1,7 +1,6 @@
 package ndb
 
 import (
-	"database/sql"
 	"encoding/json"
 	"strings"
 
@@ -81,7 +80,7 @@ func (dbb *DBBridge) DeleteWithFields(Query *Query) ([]M, error) {
 		return nil, err
 	}
 
-	return dbb.ExecuteQuery(query, args...)
+	return dbb.observed(Query).ExecuteQuery(query, args...)
 }
 
 func (dbb *DBBridge) DeleteOneWithFields(Query *Query) (M, error) {
@@ -90,7 +89,7 @@ func (dbb *DBBridge) DeleteOneWithFields(Query *Query) (M, error) {
 		return nil, err
 	}
 
-	if result, err := dbb.ExecuteQuery(query, args...); err != nil {
+	if result, err := dbb.observed(Query).ExecuteQuery(query, args...); err != nil {
 		return nil, err
 	} else if len(result) == 0 {
 		return nil, ErrNotFoundRecord
@@ -105,7 +104,7 @@ func (dbb *DBBridge) DeleteWithFieldsB(Query *Query, v any) error {
 		return err
 	}
 
-	if bytes, err := dbb.ExecuteQueryBytes(query, true, args...); err == nil {
+	if bytes, err := dbb.observed(Query).ExecuteQueryBytes(query, true, args...); err == nil {
 		return json.Unmarshal(bytes, v)
 	} else {
 		return err
@@ -118,7 +117,7 @@ func (dbb *DBBridge) DeleteOneWithFieldsB(Query *Query, v any) error {
 		return err
 	}
 
-	if bytes, err := dbb.ExecuteQueryBytes(query, false, args...); err == nil {
+	if bytes, err := dbb.observed(Query).ExecuteQueryBytes(query, false, args...); err == nil {
 		return json.Unmarshal(bytes, v)
 	} else {
 		return err
@@ -131,13 +130,7 @@ func (dbb *DBBridge) DeleteWithRowsAffected(Query *Query) (int64, error) {
 		return 0, err
 	}
 
-	var res sql.Result
-	if dbb.db != nil {
-		res, err = dbb.db.Exec(query, args...)
-	} else {
-		res, err = dbb.trx.Exec(query, args...)
-	}
-
+	res, err := dbb.observed(Query).execQuery(query, args...)
 	if err != nil {
 		return 0, err
 	}
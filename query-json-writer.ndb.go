@@ -0,0 +1,99 @@
+package ndb
+
+import (
+	"bufio"
+	"io"
+	"time"
+)
+
+// ExecuteQueryJSON runs the query and encodes every row straight into w, as a JSON
+// array or as NDJSON (one object per line). Nothing is buffered beyond a small write
+// buffer, so if an error happens mid-stream w may already hold a partial document.
+// Returns the written rows.
+func (b *DBBridge) ExecuteQueryJSON(w io.Writer, ndjson bool, query string, args ...any) (total int64, err error) {
+	defer func(start time.Time) { b.runAfterExecute(query, args, start, total, err) }(time.Now())
+
+	rows, err := b.queryRows(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, plans, ptrs, err := planColumns(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(w)
+	if !ndjson {
+		bw.WriteByte('[')
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return total, err
+		}
+
+		if total > 0 && !ndjson {
+			bw.WriteByte(',')
+		}
+
+		if err := writeJSONRow(bw, cols, plans); err != nil {
+			return total, err
+		}
+
+		if ndjson {
+			bw.WriteByte('\n')
+		}
+		total++
+	}
+
+	if err := rows.Err(); err != nil {
+		return total, b.classifyError(err)
+	}
+
+	if !ndjson {
+		bw.WriteByte(']')
+	}
+	return total, bw.Flush()
+}
+
+// ReadJSON streams the rows of a read query into w (e.g. an http.ResponseWriter).
+func (dbb *DBBridge) ReadJSON(readQuery *Query, w io.Writer, ndjson bool) (int64, error) {
+	query, args, err := dbb.BuildReadQuery(readQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbb.readBridge(readQuery).ExecuteQueryJSON(w, ndjson, query, args...)
+}
+
+// CreateJSON streams the RETURNING rows of a create query into w.
+func (dbb *DBBridge) CreateJSON(createQuery *Query, w io.Writer, ndjson bool) (int64, error) {
+	query, args, err := dbb.BuildCreateQuery(createQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbb.observed(createQuery).ExecuteQueryJSON(w, ndjson, query, args...)
+}
+
+// UpdateJSON streams the RETURNING rows of an update query into w.
+func (dbb *DBBridge) UpdateJSON(updateQuery *Query, w io.Writer, ndjson bool) (int64, error) {
+	query, args, err := dbb.BuildUpdateQuery(updateQuery, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbb.observed(updateQuery).ExecuteQueryJSON(w, ndjson, query, args...)
+}
+
+// DeleteJSON streams the RETURNING rows of a delete query into w.
+func (dbb *DBBridge) DeleteJSON(deleteQuery *Query, w io.Writer, ndjson bool) (int64, error) {
+	query, args, err := dbb.BuildDeleteQuery(deleteQuery, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbb.observed(deleteQuery).ExecuteQueryJSON(w, ndjson, query, args...)
+}
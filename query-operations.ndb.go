@@ -23,6 +23,12 @@ func (q *Query) Payload(payload M) *Query {
 	return q
 }
 
+// Payloads sets the rows inserted by CreateMany/CreateManyB.
+func (q *Query) Payloads(payloads []M) *Query {
+	q.RPayloads = payloads
+	return q
+}
+
 func (q *Query) NewJoin(schema string, typ JoinType) *Join {
 	j := &Join{PTyp: typ, q: q, BasicSchema: &BasicSchema{PSchema: schema}, POn: []M{}}
 	q.PJoins = append(q.PJoins, j)
@@ -103,8 +109,23 @@ func (q *Query) GetPayload() M {
 	return q.RPayload
 }
 
+func (q *Query) GetPayloads() []M {
+	return q.RPayloads
+}
+
 func (q *Query) GetOffset() int { return q.POffset }
 
+// Unbounded removes the LIMIT of read queries. Meant for ReadIter/CopyTo jobs
+// walking whole tables; it cannot be set from JSON or URI params.
+func (q *Query) Unbounded() *Query {
+	q.unbounded = true
+	return q
+}
+
+func (q *Query) IsUnbounded() bool {
+	return q.unbounded
+}
+
 func (q *Query) GetLimit() int {
 	if q.PLimit == 0 {
 		return 100
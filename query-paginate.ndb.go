@@ -0,0 +1,178 @@
+package ndb
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+type CountMode uint8
+
+const (
+	// COUNT_EXACT runs a separate COUNT(*) over the same FROM/JOIN/WHERE
+	COUNT_EXACT CountMode = iota
+	// COUNT_WINDOW adds COUNT(*) OVER() to the page query: one round trip
+	COUNT_WINDOW
+	// COUNT_ESTIMATE reads pg_class.reltuples: cheap on huge tables but ignores WHERE
+	COUNT_ESTIMATE
+)
+
+const windowTotalField = "ndb_total"
+
+var ErrInvalidPage = errors.New("page and size must be greater than zero")
+
+// Page is a paginated result envelope.
+type Page struct {
+	Items []M   `json:"items"`
+	Total int64 `json:"total"`
+	Page  int   `json:"page"`
+	Size  int   `json:"size"`
+	Pages int   `json:"pages"`
+}
+
+// BuildCountQuery builds a COUNT(*) over the rows the read query would return, ignoring
+// its ORDER BY, LIMIT, OFFSET and cursor. Grouped queries count their groups.
+func (dbb *DBBridge) BuildCountQuery(readQuery *Query) (string, []any, error) {
+	cq := *readQuery
+	cq.POrderBy = nil
+	cq.POffset = 0
+	cq.PCursor = ""
+	cq.PCursorDir = ""
+	cq.Unbounded()
+
+	if len(cq.PGroupBy) == 0 && !slices.ContainsFunc(cq.PFields, func(f *SQLField) bool {
+		return slices.ContainsFunc(f.POperators, func(op *SQLOperation) bool { return op.POp == DISTINCT })
+	}) {
+		cq.PFields = []*SQLField{F("*").Count().As("total")}
+		return dbb.BuildReadQuery(&cq)
+	}
+
+	inner, args, err := dbb.BuildReadQuery(&cq)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "SELECT COUNT(*) AS total FROM (" + inner + ") AS ndb_count", args, nil
+}
+
+// Count returns how many rows the read query matches.
+func (dbb *DBBridge) Count(readQuery *Query) (int64, error) {
+	query, args, err := dbb.BuildCountQuery(readQuery)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbb.readBridge(readQuery).queryTotal(query, args...)
+}
+
+func (dbb *DBBridge) queryTotal(query string, args ...any) (int64, error) {
+	result, err := dbb.ExecuteQuery(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(result) != 1 {
+		return 0, ErrNotFoundRecord
+	}
+
+	for _, v := range result[0] {
+		if n, ok := v.(int64); ok {
+			return n, nil
+		}
+	}
+
+	return 0, ErrConvert
+}
+
+// EstimateCount returns the planner row estimate of the query table (pg_class.reltuples).
+func (dbb *DBBridge) EstimateCount(readQuery *Query) (int64, error) {
+	table, err := readQuery.GetSchema(dbb)
+	if err != nil {
+		return 0, err
+	}
+
+	return dbb.readBridge(readQuery).queryTotal("SELECT reltuples::bigint AS total FROM pg_class WHERE oid = to_regclass($1)", table)
+}
+
+// Paginate reads the 1-based page of the query with an exact total.
+func (dbb *DBBridge) Paginate(readQuery *Query, page, size int) (*Page, error) {
+	return dbb.PaginateWith(readQuery, page, size, COUNT_EXACT)
+}
+
+// PaginateWith reads the 1-based page of the query, computing the total with mode.
+func (dbb *DBBridge) PaginateWith(readQuery *Query, page, size int, mode CountMode) (*Page, error) {
+	if page < 1 || size < 1 {
+		return nil, ErrInvalidPage
+	}
+
+	pageQuery := *readQuery
+	pageQuery.PLimit = size
+	pageQuery.POffset = (page - 1) * size
+
+	result := &Page{Page: page, Size: size}
+
+	switch mode {
+	case COUNT_EXACT:
+		items, err := dbb.Read(&pageQuery)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = items
+
+		if result.Total, err = dbb.Count(readQuery); err != nil {
+			return nil, err
+		}
+
+	case COUNT_WINDOW:
+		fields := pageQuery.PFields
+		if len(fields) == 0 {
+			fields = []*SQLField{F("*")}
+		}
+		pageQuery.PFields = append(slices.Clone(fields), F("*").Count().Over().As(windowTotalField))
+
+		items, err := dbb.Read(&pageQuery)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if n, ok := item[windowTotalField].(int64); ok {
+				result.Total = n
+			}
+			delete(item, windowTotalField)
+		}
+		result.Items = items
+
+		// past the last page the window has no row to report the total on
+		if len(items) == 0 && page > 1 {
+			if result.Total, err = dbb.Count(readQuery); err != nil {
+				return nil, err
+			}
+		}
+
+	case COUNT_ESTIMATE:
+		items, err := dbb.Read(&pageQuery)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = items
+
+		if result.Total, err = dbb.EstimateCount(readQuery); err != nil {
+			return nil, err
+		}
+
+		// tables never analyzed report -1
+		if result.Total < 0 {
+			if result.Total, err = dbb.Count(readQuery); err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported count mode %d", mode)
+	}
+
+	result.Pages = int((result.Total + int64(size) - 1) / int64(size))
+
+	return result, nil
+}
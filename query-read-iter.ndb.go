@@ -0,0 +1,66 @@
+package ndb
+
+import (
+	"iter"
+	"time"
+)
+
+// ReadIter builds the read query and returns an iterator over its rows. The query
+// only runs when the iterator is ranged over, and rows are scanned one at a time
+// with a single column plan, so memory stays constant. Combine with Unbounded()
+// to walk a whole table. Iteration stops after the first yielded error.
+func (dbb *DBBridge) ReadIter(readQuery *Query) iter.Seq2[M, error] {
+	query, args, err := dbb.BuildReadQuery(readQuery)
+
+	return func(yield func(M, error) bool) {
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		dbb.readBridge(readQuery).iterRows(query, args, yield)
+	}
+}
+
+func (dbb *DBBridge) iterRows(query string, args []any, yield func(M, error) bool) {
+	var (
+		total int64
+		err   error
+	)
+	defer func(start time.Time) { dbb.runAfterExecute(query, args, start, total, err) }(time.Now())
+
+	rows, err := dbb.queryRows(query, args...)
+	if err != nil {
+		yield(nil, err)
+		return
+	}
+	defer rows.Close()
+
+	cols, plans, ptrs, err := planColumns(rows)
+	if err != nil {
+		yield(nil, err)
+		return
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			yield(nil, err)
+			return
+		}
+
+		var row M
+		if row, err = readMapRow(cols, plans); err != nil {
+			yield(nil, err)
+			return
+		}
+		total++
+
+		if !yield(row, nil) {
+			return
+		}
+	}
+
+	if err = dbb.classifyError(rows.Err()); err != nil {
+		yield(nil, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lib/pq"
@@ -124,9 +125,8 @@ func scanIntoStructs(rows *sql.Rows, dest any) error {
 	cols, _ := rows.Columns()
 	elemType := sliceVal.Type().Elem()
 
-	fieldIndex := makeFieldIndex(elemType)
-
-	ptrs, setters := makeScanPlan(cols, elemType, fieldIndex)
+	plan := cachedScanPlan(elemType, cols)
+	ptrs := plan.dests()
 
 	for rows.Next() {
 		if err := rows.Scan(ptrs...); err != nil {
@@ -134,12 +134,8 @@ func scanIntoStructs(rows *sql.Rows, dest any) error {
 		}
 
 		itemVal := reflect.New(elemType).Elem()
-		for i := range setters {
-			if setters[i] != nil {
-				if err := setters[i](itemVal); err != nil {
-					return err
-				}
-			}
+		if err := plan.assign(itemVal, ptrs); err != nil {
+			return err
 		}
 
 		sliceVal.Set(reflect.Append(sliceVal, itemVal))
@@ -166,8 +162,8 @@ func scanOneIntoStruct(rows *sql.Rows, dest any) error {
 	}
 
 	cols, _ := rows.Columns()
-	fieldIndex := makeFieldIndex(elemPtr.Type())
-	ptrs, setters := makeScanPlan(cols, elemPtr.Type(), fieldIndex)
+	plan := cachedScanPlan(elemPtr.Type(), cols)
+	ptrs := plan.dests()
 
 	if !rows.Next() {
 		if err := rows.Err(); err != nil {
@@ -179,15 +175,7 @@ func scanOneIntoStruct(rows *sql.Rows, dest any) error {
 		return err
 	}
 
-	for i := range setters {
-		if setters[i] != nil {
-			if err := setters[i](elemPtr); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
+	return plan.assign(elemPtr, ptrs)
 }
 
 func makeFieldIndex(t reflect.Type) map[string]int {
@@ -209,128 +197,225 @@ func makeFieldIndex(t reflect.Type) map[string]int {
 	return idx
 }
 
-type setter func(dst reflect.Value) error
+type scanKind uint8
+
+const (
+	scanSkip scanKind = iota
+	scanTime
+	scanString
+	scanBool
+	scanFloat
+	scanInt
+	scanUint
+	scanBytes
+	scanStrings
+	scanInts
+	scanFloats
+	scanBools
+	scanJSONMap
+)
+
+type fieldScan struct {
+	field int
+	kind  scanKind
+	// ptr fields are allocated only for non NULL values
+	ptr bool
+}
+
+// scanPlan maps each result column to the struct field it is scanned into. It holds
+// no scan destinations, so one plan is shared by every query with the same columns.
+type scanPlan []fieldScan
 
-func makeScanPlan(cols []string, structType reflect.Type, idx map[string]int) ([]any, []setter) {
-	ptrs := make([]any, len(cols))
-	setters := make([]setter, len(cols))
+type scanPlanKey struct {
+	typ  reflect.Type
+	cols string
+}
+
+var scanPlans sync.Map // scanPlanKey -> scanPlan
+
+// cachedScanPlan returns the plan of structType for cols, built once per column set.
+func cachedScanPlan(structType reflect.Type, cols []string) scanPlan {
+	key := scanPlanKey{typ: structType, cols: strings.Join(cols, "\x00")}
+	if plan, ok := scanPlans.Load(key); ok {
+		return plan.(scanPlan)
+	}
+
+	plan := makeScanPlan(cols, structType, makeFieldIndex(structType))
+	scanPlans.Store(key, plan)
+	return plan
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func makeScanPlan(cols []string, structType reflect.Type, idx map[string]int) scanPlan {
+	plan := make(scanPlan, len(cols))
 
 	for i, col := range cols {
 		fieldPos, ok := idx[col]
 		if !ok {
-			var sink any
-			ptrs[i] = &sink
-			setters[i] = nil
+			plan[i] = fieldScan{field: -1, kind: scanSkip}
 			continue
 		}
 
-		field := structType.Field(fieldPos)
-		fieldType := field.Type
+		fieldType := structType.Field(fieldPos).Type
+		ptr := fieldType.Kind() == reflect.Pointer
+		if ptr {
+			fieldType = fieldType.Elem()
+		}
+		kind := scanSkip
 
 		switch {
-		case fieldType == reflect.TypeOf(time.Time{}):
-			v := new(sql.NullTime)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if v.Valid {
-					dst.Field(fieldPos).Set(reflect.ValueOf(v.Time))
-				}
-				return nil
-			}
-
+		case fieldType == timeType:
+			kind = scanTime
 		case fieldType.Kind() == reflect.String:
-			v := new(sql.NullString)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if v.Valid {
-					dst.Field(fieldPos).SetString(v.String)
-				}
-				return nil
-			}
-
+			kind = scanString
 		case fieldType.Kind() == reflect.Bool:
-			v := new(sql.NullBool)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if v.Valid {
-					dst.Field(fieldPos).SetBool(v.Bool)
-				}
-				return nil
-			}
-
+			kind = scanBool
 		case fieldType.Kind() == reflect.Float32 || fieldType.Kind() == reflect.Float64:
-			v := new(sql.NullFloat64)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if v.Valid {
-					dst.Field(fieldPos).SetFloat(v.Float64)
-				}
-				return nil
-			}
-
+			kind = scanFloat
 		case fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Int64:
-			v := new(sql.NullInt64)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if v.Valid {
-					dst.Field(fieldPos).SetInt(v.Int64)
-				}
-				return nil
-			}
-
+			kind = scanInt
 		case fieldType.Kind() >= reflect.Uint && fieldType.Kind() <= reflect.Uint64:
-			v := new(sql.NullInt64)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if v.Valid {
-					dst.Field(fieldPos).SetUint(uint64(v.Int64))
-				}
-				return nil
-			}
-
+			kind = scanUint
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8:
+			kind = scanBytes
 		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
-			a := new(pq.StringArray)
-			ptrs[i] = a
-			setters[i] = func(dst reflect.Value) error {
-				if *a != nil {
-					dst.Field(fieldPos).Set(reflect.ValueOf([]string(*a)))
-				}
-				return nil
-			}
+			kind = scanStrings
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() >= reflect.Int && fieldType.Elem().Kind() <= reflect.Int64:
+			kind = scanInts
+		case fieldType.Kind() == reflect.Slice && (fieldType.Elem().Kind() == reflect.Float32 || fieldType.Elem().Kind() == reflect.Float64):
+			kind = scanFloats
+		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Bool:
+			kind = scanBools
+		case fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String && fieldType.Elem().Kind() == reflect.Interface:
+			kind = scanJSONMap
+		}
 
-		case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Int16:
-			a := new(pq.Int64Array)
-			ptrs[i] = a
-			setters[i] = func(dst reflect.Value) error {
-				if *a != nil {
-					int64Array := []int64(*a)
-					out := make([]int16, len(int64Array))
-					for j := range int64Array {
-						out[j] = int16(int64Array[j])
-					}
-					dst.Field(fieldPos).Set(reflect.ValueOf(out))
+		if kind == scanSkip {
+			fieldPos = -1
+		}
+		plan[i] = fieldScan{field: fieldPos, kind: kind, ptr: ptr}
+	}
+
+	return plan
+}
+
+// dests returns fresh scan destinations for one rows.Scan loop.
+func (p scanPlan) dests() []any {
+	ptrs := make([]any, len(p))
+	for i, f := range p {
+		switch f.kind {
+		case scanTime:
+			ptrs[i] = new(sql.NullTime)
+		case scanString:
+			ptrs[i] = new(sql.NullString)
+		case scanBool:
+			ptrs[i] = new(sql.NullBool)
+		case scanFloat:
+			ptrs[i] = new(sql.NullFloat64)
+		case scanInt, scanUint:
+			ptrs[i] = new(sql.NullInt64)
+		case scanBytes, scanJSONMap:
+			ptrs[i] = new([]byte)
+		case scanStrings:
+			ptrs[i] = new(pq.StringArray)
+		case scanInts:
+			ptrs[i] = new(pq.Int64Array)
+		case scanFloats:
+			ptrs[i] = new(pq.Float64Array)
+		case scanBools:
+			ptrs[i] = new(pq.BoolArray)
+		default:
+			ptrs[i] = new(any)
+		}
+	}
+	return ptrs
+}
+
+// assign copies the scanned ptrs into the fields of dst; NULLs leave the zero value.
+func (p scanPlan) assign(dst reflect.Value, ptrs []any) error {
+	for i, f := range p {
+		if f.kind == scanSkip {
+			continue
+		}
+		field := dst.Field(f.field)
+
+		switch f.kind {
+		case scanTime:
+			if v := ptrs[i].(*sql.NullTime); v.Valid {
+				settable(field, f.ptr).Set(reflect.ValueOf(v.Time))
+			}
+		case scanString:
+			if v := ptrs[i].(*sql.NullString); v.Valid {
+				settable(field, f.ptr).SetString(v.String)
+			}
+		case scanBool:
+			if v := ptrs[i].(*sql.NullBool); v.Valid {
+				settable(field, f.ptr).SetBool(v.Bool)
+			}
+		case scanFloat:
+			if v := ptrs[i].(*sql.NullFloat64); v.Valid {
+				settable(field, f.ptr).SetFloat(v.Float64)
+			}
+		case scanInt:
+			if v := ptrs[i].(*sql.NullInt64); v.Valid {
+				settable(field, f.ptr).SetInt(v.Int64)
+			}
+		case scanUint:
+			if v := ptrs[i].(*sql.NullInt64); v.Valid {
+				settable(field, f.ptr).SetUint(uint64(v.Int64))
+			}
+		case scanBytes:
+			if v := ptrs[i].(*[]byte); *v != nil {
+				settable(field, f.ptr).SetBytes(*v)
+			}
+		case scanStrings:
+			if a := ptrs[i].(*pq.StringArray); *a != nil {
+				settable(field, f.ptr).Set(reflect.ValueOf([]string(*a)))
+			}
+		case scanInts:
+			if a := ptrs[i].(*pq.Int64Array); *a != nil {
+				out := makeSlice(settable(field, f.ptr), len(*a))
+				for j, n := range *a {
+					out.Index(j).SetInt(n)
 				}
-				return nil
 			}
-
-		case fieldType.Kind() == reflect.Map && fieldType.Key().Kind() == reflect.String && fieldType.Elem().Kind() == reflect.Interface:
-			v := new([]byte)
-			ptrs[i] = v
-			setters[i] = func(dst reflect.Value) error {
-				if len(*v) > 0 {
-					var m map[string]any
-					_ = json.Unmarshal(*v, &m)
-					dst.Field(fieldPos).Set(reflect.ValueOf(m))
+		case scanFloats:
+			if a := ptrs[i].(*pq.Float64Array); *a != nil {
+				out := makeSlice(settable(field, f.ptr), len(*a))
+				for j, n := range *a {
+					out.Index(j).SetFloat(n)
 				}
-				return nil
 			}
-
-		default:
-			var sink any
-			ptrs[i] = &sink
-			setters[i] = nil
+		case scanBools:
+			if a := ptrs[i].(*pq.BoolArray); *a != nil {
+				settable(field, f.ptr).Set(reflect.ValueOf([]bool(*a)))
+			}
+		case scanJSONMap:
+			if v := ptrs[i].(*[]byte); len(*v) > 0 {
+				var m map[string]any
+				_ = json.Unmarshal(*v, &m)
+				settable(field, f.ptr).Set(reflect.ValueOf(m))
+			}
 		}
 	}
 
-	return ptrs, setters
+	return nil
+}
+
+// settable returns the value to write a non NULL column into, allocating it when
+// the field is a pointer.
+func settable(field reflect.Value, ptr bool) reflect.Value {
+	if !ptr {
+		return field
+	}
+
+	v := reflect.New(field.Type().Elem())
+	field.Set(v)
+	return v.Elem()
+}
+
+func makeSlice(field reflect.Value, n int) reflect.Value {
+	field.Set(reflect.MakeSlice(field.Type(), n, n))
+	return field
 }
@@ -6,6 +6,7 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	goutils "github.com/nitsugaro/go-utils"
@@ -41,6 +42,24 @@ func (dbb *DBBridge) BuildReadQuery(readQuery *Query) (string, []any, error) {
 		return "", nil, err
 	}
 
+	if readQuery.PLimit < 0 {
+		return "", nil, ErrNegativeLimit
+	}
+
+	var shape string
+	if dbb.shapes != nil {
+		if s, shapeArgs, ok := readShape(readQuery); ok {
+			if queryStr, hit := dbb.cachedShape(s); hit {
+				if logEnabled {
+					color.Green(queryStr)
+				}
+
+				return queryStr, shapeArgs, nil
+			}
+			shape = s
+		}
+	}
+
 	fields, err := readQuery.GetFormattedFields(dbb.schemaPrefix)
 	if err != nil {
 		return "", nil, err
@@ -120,12 +139,42 @@ func (dbb *DBBridge) BuildReadQuery(readQuery *Query) (string, []any, error) {
 		args = append(args, onArgs...)
 	}
 
-	whereArgs, _, err := dbb.buildConditionClauseB(query, readQuery.PWhere, pos, "WHERE")
-	if err != nil {
-		return "", nil, err
+	var cursor *cursorPayload
+	if readQuery.PCursor != "" {
+		if cursor, err = dbb.resolveCursor(readQuery); err != nil {
+			return "", nil, err
+		}
 	}
 
-	args = append(args, whereArgs...)
+	if cursor == nil {
+		whereArgs, _, err := dbb.buildConditionClauseB(query, readQuery.PWhere, pos, "WHERE")
+		if err != nil {
+			return "", nil, err
+		}
+
+		args = append(args, whereArgs...)
+	} else {
+		where := &strings.Builder{}
+		whereArgs, newPos, err := dbb.buildConditionClauseB(where, readQuery.PWhere, pos, "")
+		if err != nil {
+			return "", nil, err
+		}
+
+		query.WriteString(" WHERE ")
+		if where.Len() != 0 {
+			query.WriteByte('(')
+			query.WriteString(strings.TrimSpace(where.String()))
+			query.WriteString(") AND ")
+		}
+
+		cursorArgs, err := dbb.writeCursorCondition(query, readQuery, cursor, newPos)
+		if err != nil {
+			return "", nil, err
+		}
+
+		args = append(args, whereArgs...)
+		args = append(args, cursorArgs...)
+	}
 
 	if len(readQuery.PGroupBy) != 0 {
 		if fields, err := ValidParseSqlFields(dbb.schemaPrefix, readQuery.PGroupBy); err != nil {
@@ -141,6 +190,11 @@ func (dbb *DBBridge) BuildReadQuery(readQuery *Query) (string, []any, error) {
 			return "", nil, err
 		} else {
 			order := strings.ToUpper(readQuery.POrderBy[len(readQuery.POrderBy)-1].PName)
+			// reading before a cursor walks the sequence backwards, ReadKeyset restores the order
+			if cursor != nil && cursor.Dir == CURSOR_BEFORE {
+				order = map[string]string{"ASC": "DESC", "DESC": "ASC"}[order]
+			}
+
 			if order == "ASC" || order == "DESC" {
 				query.WriteString(" ORDER BY ")
 				query.WriteString(strings.Join(fields, ","))
@@ -150,8 +204,10 @@ func (dbb *DBBridge) BuildReadQuery(readQuery *Query) (string, []any, error) {
 		}
 	}
 
-	query.WriteString(" LIMIT ")
-	query.WriteString(strconv.Itoa((readQuery.GetLimit())))
+	if !readQuery.IsUnbounded() {
+		query.WriteString(" LIMIT ")
+		query.WriteString(strconv.Itoa((readQuery.GetLimit())))
+	}
 
 	if readQuery.POffset != 0 {
 		query.WriteString(" OFFSET ")
@@ -159,6 +215,10 @@ func (dbb *DBBridge) BuildReadQuery(readQuery *Query) (string, []any, error) {
 	}
 
 	queryStr := query.String()
+	if shape != "" {
+		dbb.shapes.lru.Add(shape, queryStr)
+	}
+
 	if logEnabled {
 		color.Green(queryStr)
 	}
@@ -170,17 +230,20 @@ func (dbb *DBBridge) Read(readQuery *Query) ([]M, error) {
 	if query, args, err := dbb.BuildReadQuery(readQuery); err != nil {
 		return nil, err
 	} else {
-		return dbb.ExecuteQuery(query, args...)
+		return dbb.readBridge(readQuery).ExecuteQuery(query, args...)
 	}
 }
 
-func (dbb *DBBridge) ReadB(readQuery *Query, dest any) error {
+func (dbb *DBBridge) ReadB(readQuery *Query, dest any) (err error) {
 	query, args, err := dbb.BuildReadQuery(readQuery)
 	if err != nil {
 		return err
 	}
 
-	rows, err := dbb.queryRows(query, args...)
+	reader := dbb.readBridge(readQuery)
+	defer func(start time.Time) { reader.runAfterExecute(query, args, start, destLen(dest), err) }(time.Now())
+
+	rows, err := reader.queryRows(query, args...)
 	if err != nil {
 		return err
 	}
@@ -209,7 +272,7 @@ func (dbb *DBBridge) ReadB(readQuery *Query, dest any) error {
 	return fmt.Errorf("unsupported ReadB target element type: %s", elemType.Kind().String())
 }
 
-func (dbb *DBBridge) ReadOneB(readOneQuery *Query, dest any) error {
+func (dbb *DBBridge) ReadOneB(readOneQuery *Query, dest any) (err error) {
 	q := readOneQuery.Limit(1)
 
 	query, args, err := dbb.BuildReadQuery(q)
@@ -217,7 +280,16 @@ func (dbb *DBBridge) ReadOneB(readOneQuery *Query, dest any) error {
 		return err
 	}
 
-	rows, err := dbb.queryRows(query, args...)
+	reader := dbb.readBridge(q)
+	defer func(start time.Time) {
+		var total int64
+		if err == nil {
+			total = 1
+		}
+		reader.runAfterExecute(query, args, start, total, err)
+	}(time.Now())
+
+	rows, err := reader.queryRows(query, args...)
 	if err != nil {
 		return err
 	}
@@ -241,3 +313,12 @@ func (dbb *DBBridge) ReadOneB(readOneQuery *Query, dest any) error {
 
 	return fmt.Errorf("unsupported ReadOneB target type: %s", elem.Kind().String())
 }
+
+// destLen reports how many rows ReadB scanned into dest.
+func destLen(dest any) int64 {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return 0
+	}
+	return int64(rv.Elem().Len())
+}
@@ -42,7 +42,7 @@ func NewQueryFromURIParams(schema string, method string, params map[string][]str
 	}
 
 	if v := first(params, "l"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
 			q.Limit(n)
 		}
 	}
@@ -53,6 +53,10 @@ func NewQueryFromURIParams(schema string, method string, params map[string][]str
 		}
 	}
 
+	if v := first(params, "c"); v != "" && method == "GET" {
+		q.Cursor(v)
+	}
+
 	if len(params["j"]) != 0 && method == "GET" {
 		for _, raw := range params["j"] {
 			applyJoin(q, raw)
@@ -0,0 +1,181 @@
+package ndb
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru"
+)
+
+type ShapeCacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// shapeCache memoizes the SQL text BuildReadQuery generates per structural
+// fingerprint of the query: everything that ends up in the SQL except the values
+// bound as args. It is shared by a bridge, its copies and its transaction bridges.
+type shapeCache struct {
+	lru    *lru.Cache
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newShapeCache(size int) *shapeCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+
+	return &shapeCache{lru: cache}
+}
+
+// readShape returns the fingerprint of a read query along with its args, in the
+// order BuildReadQuery binds them, so a cache hit walks the query once. It reports
+// false when the SQL depends on more than the structure: REST queries check the
+// schema config, cursors carry signed values and subqueries run their own
+// middlewares while being built.
+func readShape(q *Query) (string, []any, bool) {
+	if q.asRestCollection || q.asRestResource || q.PCursor != "" || q.subQuery != nil {
+		return "", nil, false
+	}
+
+	var args []any
+	b := &strings.Builder{}
+	b.Grow(256)
+
+	b.WriteString(q.PSchema)
+	b.WriteByte(0)
+
+	writeFieldsShape(b, 'F', q.PFields)
+
+	for _, join := range q.PJoins {
+		b.WriteByte('J')
+		b.WriteString(string(join.PTyp))
+		b.WriteByte(0)
+		b.WriteString(join.PSchema)
+		b.WriteByte(0)
+		writeConditionShape(b, join.POn, &args)
+	}
+
+	b.WriteByte('W')
+	writeConditionShape(b, q.PWhere, &args)
+
+	writeFieldsShape(b, 'G', q.PGroupBy)
+	writeFieldsShape(b, 'O', q.POrderBy)
+
+	b.WriteByte('L')
+	b.WriteString(strconv.Itoa(q.PLimit))
+	if q.unbounded {
+		b.WriteByte('U')
+	}
+	b.WriteByte('S')
+	b.WriteString(strconv.Itoa(q.POffset))
+
+	return b.String(), args, true
+}
+
+func writeFieldsShape(b *strings.Builder, tag byte, fields []*SQLField) {
+	b.WriteByte(tag)
+	for _, f := range fields {
+		b.WriteString(f.PName)
+		for _, op := range f.POperators {
+			b.WriteByte('(')
+			b.WriteString(strconv.Itoa(int(op.POp)))
+			for _, arg := range op.PArgs {
+				b.WriteByte(',')
+				b.WriteString(arg)
+			}
+			b.WriteByte(')')
+		}
+		b.WriteByte(0)
+	}
+}
+
+// writeConditionShape mirrors parseAndGroupToBuilder: keys and operators are part of
+// the shape, so are the values that are written into the SQL (IN lengths, IS NULL
+// flags, eq_field columns); the rest are collected as args. Values the builder
+// rejects get a '!' marker; those shapes never build, so they never reach the cache.
+func writeConditionShape(b *strings.Builder, clauseArr []M, args *[]any) {
+	b.WriteByte('[')
+	for _, group := range clauseArr {
+		writeGroupShape(b, group, args)
+	}
+	b.WriteByte(']')
+}
+
+func writeGroupShape(b *strings.Builder, group M, args *[]any) {
+	b.WriteByte('{')
+	for _, key := range sortedKeys(group, make([]string, 0, 8)) {
+		b.WriteString(key)
+		b.WriteByte(':')
+
+		val := group[key]
+		if key == "not" {
+			if notGroup, ok := val.(M); ok {
+				writeGroupShape(b, notGroup, args)
+			} else {
+				b.WriteByte('!')
+			}
+			continue
+		}
+
+		v, ok := val.(M)
+		if !ok {
+			b.WriteByte('=')
+			*args = append(*args, val)
+			continue
+		}
+
+		for _, op := range sortedKeys(v, make([]string, 0, 4)) {
+			b.WriteString(op)
+			b.WriteByte(' ')
+
+			switch strings.ToLower(op) {
+			case "in", "notin", "not_in":
+				if arr, ok := v[op].([]any); ok {
+					b.WriteString(strconv.Itoa(len(arr)))
+					*args = append(*args, arr...)
+				} else {
+					b.WriteByte('!')
+				}
+			case "isnull", "is_null":
+				if isNull, ok := v[op].(bool); ok {
+					b.WriteString(strconv.FormatBool(isNull))
+				} else {
+					b.WriteByte('!')
+				}
+			case "eq_field", "eqf":
+				if eqField, ok := v[op].(string); ok {
+					b.WriteString(eqField)
+				} else {
+					b.WriteByte('!')
+				}
+			default:
+				*args = append(*args, v[op])
+			}
+			b.WriteByte(0)
+		}
+	}
+	b.WriteByte('}')
+}
+
+func (dbb *DBBridge) cachedShape(shape string) (string, bool) {
+	if v, ok := dbb.shapes.lru.Get(shape); ok {
+		dbb.shapes.hits.Add(1)
+		return v.(string), true
+	}
+
+	dbb.shapes.misses.Add(1)
+	return "", false
+}
+
+func (dbb *DBBridge) QueryShapeCacheStats() ShapeCacheStats {
+	if dbb.shapes == nil {
+		return ShapeCacheStats{}
+	}
+
+	return ShapeCacheStats{Hits: dbb.shapes.hits.Load(), Misses: dbb.shapes.misses.Load(), Size: dbb.shapes.lru.Len()}
+}
@@ -0,0 +1,96 @@
+package ndb
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ReadAll runs the read query and scans its rows into T, a struct whose fields are
+// matched to the columns by json tag or lowercased name, like ReadB.
+func ReadAll[T any](dbb *DBBridge, readQuery *Query) ([]T, error) {
+	query, args, err := dbb.BuildReadQuery(readQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll[T](dbb.readBridge(readQuery), query, args)
+}
+
+// ReadFirst reads a single row into T, sql.ErrNoRows when there is none.
+func ReadFirst[T any](dbb *DBBridge, readQuery *Query) (T, error) {
+	var zero T
+
+	out, err := ReadAll[T](dbb, readQuery.Limit(1))
+	if err != nil {
+		return zero, err
+	}
+	if len(out) == 0 {
+		return zero, sql.ErrNoRows
+	}
+
+	return out[0], nil
+}
+
+// CreateReturning runs the create query and scans its RETURNING rows into T.
+func CreateReturning[T any](dbb *DBBridge, createQuery *Query) ([]T, error) {
+	query, args, err := dbb.BuildCreateQuery(createQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll[T](dbb.observed(createQuery), query, args)
+}
+
+// UpdateReturning runs the update query and scans the updated rows into T.
+func UpdateReturning[T any](dbb *DBBridge, updateQuery *Query) ([]T, error) {
+	query, args, err := dbb.BuildUpdateQuery(updateQuery, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAll[T](dbb.observed(updateQuery), query, args)
+}
+
+// scanAll scans every row straight into T with the cached scan plan of its columns.
+func scanAll[T any](b *DBBridge, query string, args []any) (out []T, err error) {
+	structType := reflect.TypeFor[T]()
+	if structType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported typed target: %s", structType)
+	}
+
+	defer func(start time.Time) { b.runAfterExecute(query, args, start, int64(len(out)), err) }(time.Now())
+
+	rows, err := b.queryRows(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := cachedScanPlan(structType, cols)
+	ptrs := plan.dests()
+
+	out = make([]T, 0, 8)
+	for rows.Next() {
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		var item T
+		if err = plan.assign(reflect.ValueOf(&item).Elem(), ptrs); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+
+	if err = b.classifyError(rows.Err()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
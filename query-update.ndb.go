@@ -1,7 +1,6 @@
 package ndb
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -38,9 +37,9 @@ func (dbb *DBBridge) BuildUpdateQuery(updateQuery *Query, returning bool) (strin
 			pos  = 1
 		)
 
-		for k, v := range updateQuery.RPayload {
+		for _, k := range sortedKeys(updateQuery.RPayload, make([]string, 0, len(updateQuery.RPayload))) {
 			sets = append(sets, fmt.Sprintf("%s = $%d", k, pos))
-			args = append(args, v)
+			args = append(args, updateQuery.RPayload[k])
 			pos++
 		}
 
@@ -138,7 +137,7 @@ func (dbb *DBBridge) UpdateWithFields(updateQuery *Query) ([]M, error) {
 		return nil, err
 	}
 
-	return dbb.ExecuteQuery(query, args...)
+	return dbb.observed(updateQuery).ExecuteQuery(query, args...)
 }
 
 func (dbb *DBBridge) UpdateOneWithFields(updateQuery *Query) (M, error) {
@@ -147,7 +146,7 @@ func (dbb *DBBridge) UpdateOneWithFields(updateQuery *Query) (M, error) {
 		return nil, err
 	}
 
-	if result, err := dbb.ExecuteQuery(query, args...); err != nil {
+	if result, err := dbb.observed(updateQuery).ExecuteQuery(query, args...); err != nil {
 		return nil, err
 	} else if len(result) == 0 {
 		return nil, ErrNotFoundRecord
@@ -162,7 +161,7 @@ func (dbb *DBBridge) UpdateWithFieldsB(updateQuery *Query, v any) error {
 		return err
 	}
 
-	if bytes, err := dbb.ExecuteQueryBytes(query, true, args...); err == nil {
+	if bytes, err := dbb.observed(updateQuery).ExecuteQueryBytes(query, true, args...); err == nil {
 		return json.Unmarshal(bytes, v)
 	} else {
 		return err
@@ -175,7 +174,7 @@ func (dbb *DBBridge) UpdateOneWithFieldsB(updateQuery *Query, v any) error {
 		return err
 	}
 
-	if bytes, err := dbb.ExecuteQueryBytes(query, false, args...); err == nil {
+	if bytes, err := dbb.observed(updateQuery).ExecuteQueryBytes(query, false, args...); err == nil {
 		return json.Unmarshal(bytes, v)
 	} else {
 		return err
@@ -188,13 +187,7 @@ func (dbb *DBBridge) UpdateWithRowsAffected(updateQuery *Query) (int64, error) {
 		return 0, err
 	}
 
-	var res sql.Result
-	if dbb.db != nil {
-		res, err = dbb.db.Exec(query, args...)
-	} else {
-		res, err = dbb.trx.Exec(query, args...)
-	}
-
+	res, err := dbb.observed(updateQuery).execQuery(query, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -36,94 +38,120 @@ type colPlan struct {
 }
 
 func (b *DBBridge) queryRows(query string, args ...any) (*sql.Rows, error) {
+	b.markWrite(query)
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if b.useStmtCache(query) {
+		rows, err = b.queryStmt(query, args...)
+	} else {
+		rows, err = b.queryDirect(query, args...)
+	}
+	return rows, b.classifyError(err)
+}
+
+func (b *DBBridge) queryDirect(query string, args ...any) (*sql.Rows, error) {
 	if b.db != nil {
-		return b.db.Query(query, args...)
+		return b.db.QueryContext(b.Context(), query, args...)
+	}
+	return b.trx.QueryContext(b.Context(), query, args...)
+}
+
+func (b *DBBridge) execQuery(query string, args ...any) (sql.Result, error) {
+	b.markWrite(query)
+
+	var (
+		res   sql.Result
+		err   error
+		start = time.Now()
+	)
+	if b.useStmtCache(query) {
+		res, err = b.execStmt(query, args...)
+	} else {
+		res, err = b.execDirect(query, args...)
 	}
-	return b.trx.Query(query, args...)
+	err = b.classifyError(err)
+
+	var affected int64
+	if err == nil {
+		affected, _ = res.RowsAffected()
+	}
+	b.runAfterExecute(query, args, start, affected, err)
+
+	return res, err
 }
 
-func (b *DBBridge) ExecuteQuery(query string, args ...any) ([]M, error) {
+func (b *DBBridge) execDirect(query string, args ...any) (sql.Result, error) {
+	if b.db != nil {
+		return b.db.ExecContext(b.Context(), query, args...)
+	}
+	return b.trx.ExecContext(b.Context(), query, args...)
+}
+
+func (b *DBBridge) ExecuteQuery(query string, args ...any) (out []M, err error) {
+	defer func(start time.Time) { b.runAfterExecute(query, args, start, int64(len(out)), err) }(time.Now())
+
 	rows, err := b.queryRows(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	cols, err := rows.Columns()
+	cols, plans, ptrs, err := planColumns(rows)
 	if err != nil {
 		return nil, err
 	}
-	ct, err := rows.ColumnTypes()
-	if err != nil {
-		return nil, err
-	}
-
-	plans := make([]colPlan, len(cols))
-	ptrs := make([]any, len(cols))
-
-	for i := range cols {
-		t := normalizeDBType(ct[i].DatabaseTypeName())
-		p := makeColPlanForMap(t)
-		p.dbTyp = t
-		plans[i] = p
-		ptrs[i] = p.ptr
-	}
 
-	out := make([]M, 0, 8)
+	out = make([]M, 0, 8)
 
 	for rows.Next() {
 		if err := rows.Scan(ptrs...); err != nil {
 			return nil, err
 		}
 
-		row := M{}
-		for i := range cols {
-			v, err := readPlannedValueForMap(plans[i])
-			if err != nil {
-				return nil, fmt.Errorf("col=%s type=%s: %w", cols[i], plans[i].dbTyp, err)
-			}
-			row[cols[i]] = v
+		row, err := readMapRow(cols, plans)
+		if err != nil {
+			return nil, err
 		}
 		out = append(out, row)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, b.classifyError(err)
 	}
 	return out, nil
 }
 
-func (b *DBBridge) ExecuteQueryBytes(query string, arrayValue bool, args ...any) ([]byte, error) {
+// readMapRow converts the scanned row into a map using the column plans.
+func readMapRow(cols []string, plans []colPlan) (M, error) {
+	row := make(M, len(cols))
+	for i := range cols {
+		v, err := readPlannedValueForMap(plans[i])
+		if err != nil {
+			return nil, fmt.Errorf("col=%s type=%s: %w", cols[i], plans[i].dbTyp, err)
+		}
+		row[cols[i]] = v
+	}
+	return row, nil
+}
+
+func (b *DBBridge) ExecuteQueryBytes(query string, arrayValue bool, args ...any) (_ []byte, err error) {
+	var total int64
+	defer func(start time.Time) { b.runAfterExecute(query, args, start, total, err) }(time.Now())
+
 	rows, err := b.queryRows(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	cols, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-	ct, err := rows.ColumnTypes()
+	cols, plans, ptrs, err := planColumns(rows)
 	if err != nil {
 		return nil, err
 	}
 
-	plans := make([]colPlan, len(cols))
-	ptrs := make([]any, len(cols))
-
-	for i := range cols {
-		kb, _ := json.Marshal(cols[i])
-		key := append(kb, ':')
-
-		t := normalizeDBType(ct[i].DatabaseTypeName())
-		p := makeColPlanForJSON(t)
-		p.key = key
-		p.dbTyp = t
-		plans[i] = p
-		ptrs[i] = p.ptr
-	}
-
 	var out bytes.Buffer
 	if arrayValue {
 		out.WriteByte('[')
@@ -141,24 +169,14 @@ func (b *DBBridge) ExecuteQueryBytes(query string, arrayValue bool, args ...any)
 		}
 		firstRow = false
 
-		out.WriteByte('{')
-		for i := range plans {
-			if i > 0 {
-				out.WriteByte(',')
-			}
-			out.Write(plans[i].key)
-
-			vb, err := readPlannedValueForJSON(plans[i])
-			if err != nil {
-				return nil, fmt.Errorf("col=%s type=%s: %w", cols[i], plans[i].dbTyp, err)
-			}
-			out.Write(vb)
+		if err := writeJSONRow(&out, cols, plans); err != nil {
+			return nil, err
 		}
-		out.WriteByte('}')
+		total++
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, b.classifyError(err)
 	}
 
 	if arrayValue {
@@ -167,6 +185,57 @@ func (b *DBBridge) ExecuteQueryBytes(query string, arrayValue bool, args ...any)
 	return out.Bytes(), nil
 }
 
+// planColumns prepares the scan destinations (with their JSON keys) for every result column.
+func planColumns(rows *sql.Rows) ([]string, []colPlan, []any, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ct, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	plans := make([]colPlan, len(cols))
+	ptrs := make([]any, len(cols))
+
+	for i := range cols {
+		kb, _ := json.Marshal(cols[i])
+
+		t := normalizeDBType(ct[i].DatabaseTypeName())
+		p := makeColPlanForJSON(t)
+		p.key = append(kb, ':')
+		p.dbTyp = t
+		plans[i] = p
+		ptrs[i] = p.ptr
+	}
+
+	return cols, plans, ptrs, nil
+}
+
+type rowWriter interface {
+	io.Writer
+	io.ByteWriter
+}
+
+// writeJSONRow encodes the scanned row as a JSON object using the column plans.
+func writeJSONRow(w rowWriter, cols []string, plans []colPlan) error {
+	w.WriteByte('{')
+	for i := range plans {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.Write(plans[i].key)
+
+		vb, err := readPlannedValueForJSON(plans[i])
+		if err != nil {
+			return fmt.Errorf("col=%s type=%s: %w", cols[i], plans[i].dbTyp, err)
+		}
+		w.Write(vb)
+	}
+	return w.WriteByte('}')
+}
+
 func normalizeDBType(t string) string {
 	t = strings.ToUpper(strings.TrimSpace(t))
 	if strings.HasSuffix(t, "[]") {
@@ -0,0 +1,118 @@
+package ndb
+
+import (
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type ReplicaPolicy uint8
+
+const (
+	ROUND_ROBIN ReplicaPolicy = iota
+	LEAST_CONNECTIONS
+)
+
+// replicaRouter is shared by a bridge, its WithContext copies and its transaction bridges.
+type replicaRouter struct {
+	replicas  []*sql.DB
+	policy    ReplicaPolicy
+	window    time.Duration
+	next      atomic.Uint64
+	lastWrite atomic.Int64
+}
+
+func (r *replicaRouter) pick() *sql.DB {
+	if r.policy == LEAST_CONNECTIONS {
+		best := r.replicas[0]
+		bestInUse := best.Stats().InUse
+		for _, db := range r.replicas[1:] {
+			if inUse := db.Stats().InUse; inUse < bestInUse {
+				best, bestInUse = db, inUse
+			}
+		}
+		return best
+	}
+
+	return r.replicas[(r.next.Add(1)-1)%uint64(len(r.replicas))]
+}
+
+// markWrite notes a statement run on the primary: writes and DDL open the
+// read-your-writes window, inside a transaction only once it commits. Reads,
+// including raw SELECTs, leave the replicas in use.
+func (b *DBBridge) markWrite(query string) {
+	if b.reading || !isWriteStatement(query) {
+		return
+	}
+	if b.hooks != nil {
+		b.hooks.wrote = true
+		return
+	}
+	b.openWriteWindow()
+}
+
+// openWriteWindow starts the read-your-writes window: reads go to the primary until it expires.
+func (b *DBBridge) openWriteWindow() {
+	if b.router != nil && b.router.window > 0 {
+		b.router.lastWrite.Store(time.Now().UnixNano())
+	}
+}
+
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "COPY": true, "TRUNCATE": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "COMMENT": true, "GRANT": true, "REVOKE": true,
+}
+
+// isWriteStatement reports whether query changes data or schema, judging by its
+// first keyword; a WITH query counts as a write when one of its parts modifies data.
+func isWriteStatement(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	end := strings.IndexFunc(query, func(r rune) bool { return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') })
+	if end < 0 {
+		end = len(query)
+	}
+
+	keyword := strings.ToUpper(query[:end])
+	if keyword != "WITH" {
+		return writeKeywords[keyword]
+	}
+
+	for _, word := range strings.FieldsFunc(strings.ToUpper(query[end:]), func(r rune) bool { return !('A' <= r && r <= 'Z') }) {
+		switch word {
+		case "INSERT", "UPDATE", "DELETE", "MERGE":
+			return true
+		}
+	}
+	return false
+}
+
+// readBridge returns the bridge a READ query must run on: a replica when configured,
+// unless the query asked for Primary(), the bridge is in a transaction or a write
+// happened within the read-your-writes window.
+func (b *DBBridge) readBridge(q *Query) *DBBridge {
+	if b.trx != nil || b.router == nil || len(b.router.replicas) == 0 {
+		return b.observed(q)
+	}
+
+	reader := *b
+	reader.reading = true
+	reader.query = q
+
+	if q != nil && q.primary {
+		return &reader
+	}
+
+	if w := b.router.window; w > 0 && time.Since(time.Unix(0, b.router.lastWrite.Load())) < w {
+		return &reader
+	}
+
+	reader.db = b.router.pick()
+	return &reader
+}
+
+// Primary forces a read query to run on the primary even when replicas are configured.
+func (q *Query) Primary() *Query {
+	q.primary = true
+	return q
+}
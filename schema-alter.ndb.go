@@ -24,14 +24,21 @@ func genIndexName(schemaName, col string) string {
 }
 
 func (dbb *DBBridge) generateAlterSchemaSQL(schemaName string, fields []*AlterField) (string, *Schema, error) {
-	fullTableName := fmt.Sprintf("\"%s%s\"", dbb.schemaPrefix, schemaName)
-	var sql strings.Builder
-
 	schema, ok := dbb.GetSchemaByName(schemaName)
 	if !ok {
 		return "", nil, ErrSchemaKeyNotFound
 	}
 
+	return dbb.alterSchemaSQL(schema, fields)
+}
+
+// alterSchemaSQL generates the ALTER statements of fields over schema, which is left
+// untouched, and returns the schema they produce.
+func (dbb *DBBridge) alterSchemaSQL(schema *Schema, fields []*AlterField) (string, *Schema, error) {
+	schemaName := schema.PName
+	fullTableName := fmt.Sprintf("\"%s%s\"", dbb.schemaPrefix, schemaName)
+	var sql strings.Builder
+
 	newSchema := Ptr(*schema)
 	newSchema.PFields = goutils.Map(newSchema.PFields, func(f *SchemaField, _ int) *SchemaField { return Ptr(*f) })
 	for _, field := range fields {
@@ -0,0 +1,377 @@
+package ndb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrDestructivePlan = errors.New("plan has destructive steps")
+
+// PlanStep is one statement of a SchemaDiff. Column steps carry the AlterField
+// they were generated from; index, FK and constraint steps only their SQL.
+type PlanStep struct {
+	Description string      `json:"description"`
+	SQL         string      `json:"sql"`
+	Field       *AlterField `json:"field,omitempty"`
+	// Destructive steps drop objects, narrow a column type or make a column NOT NULL
+	Destructive bool `json:"destructive,omitempty"`
+}
+
+// SchemaDiff is the migration from the live table to a desired schema.
+type SchemaDiff struct {
+	Desired *Schema `json:"-"`
+	// Create is set when the table does not exist: the plan is its CREATE TABLE
+	Create bool `json:"create,omitempty"`
+	// Fields are the column changes, in the order ModifySchema takes them
+	Fields []*AlterField `json:"fields,omitempty"`
+	// Steps run in order: constraint and index drops, column changes, then additions
+	Steps []*PlanStep `json:"steps,omitempty"`
+	// Drift lists where the stored schema and the live table diverge
+	Drift []string `json:"drift,omitempty"`
+}
+
+func (d *SchemaDiff) Empty() bool {
+	return len(d.Steps) == 0
+}
+
+func (d *SchemaDiff) Destructive() bool {
+	return slices.ContainsFunc(d.Steps, func(s *PlanStep) bool { return s.Destructive })
+}
+
+// Diff compares desired with the live table, read through IntrospectTable, and with
+// the stored schema of the same name. Indexes and constraints are matched by the
+// names CreateSchema gives them.
+func (dbb *DBBridge) Diff(desired *Schema) (*SchemaDiff, error) {
+	diff := &SchemaDiff{Desired: desired}
+	stored, hasStored := dbb.GetSchemaByName(desired.PName)
+
+	live, err := dbb.IntrospectTable(desired.PName)
+	if errors.Is(err, ErrNotFoundIntrospectTable) {
+		if hasStored {
+			diff.Drift = append(diff.Drift, "table is stored but does not exist")
+		}
+		diff.Create = true
+		diff.Steps = []*PlanStep{{Description: "create table " + desired.PName, SQL: dbb.generateCreateSchemaSQL(desired)}}
+		return diff, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasStored {
+		diff.Drift = append(diff.Drift, "table exists but is not stored")
+	} else {
+		drift, err := dbb.diffSchemas(stored, live)
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range drift.Steps {
+			diff.Drift = append(diff.Drift, "stored schema needs: "+step.Description)
+		}
+		diff.Drift = append(diff.Drift, drift.Drift...)
+	}
+
+	planned, err := dbb.diffSchemas(live, desired)
+	if err != nil {
+		return nil, err
+	}
+	diff.Fields, diff.Steps = planned.Fields, planned.Steps
+	diff.Drift = append(diff.Drift, planned.Drift...)
+
+	return diff, nil
+}
+
+// Plan renders the diff as a SQL script, destructive steps flagged.
+func (dbb *DBBridge) Plan(diff *SchemaDiff) string {
+	var sb strings.Builder
+	for _, drift := range diff.Drift {
+		sb.WriteString("-- drift: " + drift + "\n")
+	}
+
+	for _, step := range diff.Steps {
+		if step.Destructive {
+			sb.WriteString("-- DESTRUCTIVE: ")
+		} else {
+			sb.WriteString("-- ")
+		}
+		sb.WriteString(step.Description)
+		sb.WriteByte('\n')
+		sb.WriteString(strings.TrimSpace(step.SQL))
+		sb.WriteString("\n\n")
+	}
+
+	return sb.String()
+}
+
+// Apply runs the steps of diff in one transaction and saves the desired schema into
+// the schema storage. Destructive plans fail with ErrDestructivePlan unless allowed.
+func (dbb *DBBridge) Apply(diff *SchemaDiff, allowDestructive bool) error {
+	if diff.Destructive() && !allowDestructive {
+		return ErrDestructivePlan
+	}
+
+	// desired takes over the stored identity with its own copy: Save updates the
+	// metadata in place and must not touch the replaced schema
+	desired := dbb.savedSchema(diff.Desired)
+	if stored, ok := dbb.GetSchemaByName(desired.PName); ok && stored != desired && stored.Metadata != nil {
+		desired.Metadata = Ptr(*stored.Metadata)
+	}
+
+	return dbb.schemaTransaction(desired.PName, desired, func(tx *DBBridge) error {
+		for _, step := range diff.Steps {
+			if _, err := tx.execQuery(step.SQL); err != nil {
+				return fmt.Errorf("%s: %w", step.Description, err)
+			}
+		}
+		if err := tx.recordDiff(diff); err != nil {
+			return err
+		}
+
+		return dbb.schemaStorage.Save(desired)
+	})
+}
+
+// diffSchemas plans the migration of the table described by from to target.
+func (dbb *DBBridge) diffSchemas(from, target *Schema) (*SchemaDiff, error) {
+	table := dbb.schemaPrefix + target.PName
+	diff := &SchemaDiff{Desired: target}
+	var drops, columns, adds []*PlanStep
+
+	addColumn := func(af *AlterField, description string, destructive bool) error {
+		sql, _, err := dbb.alterSchemaSQL(from, []*AlterField{af})
+		if err != nil {
+			return err
+		}
+		diff.Fields = append(diff.Fields, af)
+		columns = append(columns, &PlanStep{Description: description, SQL: sql, Field: af, Destructive: destructive})
+		return nil
+	}
+
+	for _, f := range target.PFields {
+		old := from.GetField(f.PName)
+		if old == nil {
+			if err := addColumn(&AlterField{Field: Ptr(*f), AlterAction: ADD_COLUMN}, "add column "+f.PName, false); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if changes := columnChanges(old, f); len(changes) != 0 {
+			if isSerialType(old.PType) || isSerialType(f.PType) {
+				diff.Drift = append(diff.Drift, fmt.Sprintf("serial column %s differs (%s) and is left to migrate by hand", f.PName, strings.Join(changes, ", ")))
+			} else if err := addColumn(&AlterField{Field: Ptr(*f), AlterAction: ALTER_COLUMN}, "alter column "+f.PName+": "+strings.Join(changes, ", "), isNarrowing(old, f) || old.PNullable && !f.PNullable); err != nil {
+				return nil, err
+			}
+		}
+
+		if old.PUnique != f.PUnique {
+			constraint := quoteIdent(table + "_" + f.PName + "_key")
+			if f.PUnique {
+				adds = append(adds, &PlanStep{Description: "add unique " + f.PName, SQL: fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s);", quoteIdent(table), constraint, f.PName)})
+			} else {
+				drops = append(drops, &PlanStep{Description: "drop unique " + f.PName, SQL: fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", quoteIdent(table), constraint), Destructive: true})
+			}
+		}
+	}
+
+	for _, f := range from.PFields {
+		if target.GetField(f.PName) == nil {
+			if err := addColumn(&AlterField{Field: Ptr(*f), AlterAction: DROP_COLUMN}, "drop column "+f.PName, true); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// FKs and enum checks of every target column, new ones included
+	for _, f := range target.PFields {
+		old := from.GetField(f.PName)
+		if old == nil {
+			old = &SchemaField{}
+		}
+
+		if !sameForeignKey(old.PForeignKey, f.PForeignKey) {
+			constraint := quoteIdent(table + "_" + f.PName + "_fkey")
+			if old.PForeignKey != nil {
+				drops = append(drops, &PlanStep{Description: "drop foreign key " + f.PName, SQL: fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", quoteIdent(table), constraint), Destructive: true})
+			}
+			if f.PForeignKey != nil {
+				adds = append(adds, &PlanStep{Description: "add foreign key " + f.PName, SQL: fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) %s;", quoteIdent(table), constraint, f.PName, dbb.referencesSQL(f.PForeignKey))})
+			}
+		}
+
+		if !slices.Equal(enumValues(old), enumValues(f)) {
+			constraint := quoteIdent(table + "_" + f.PName + "_check")
+			if len(old.PEnumValues) != 0 {
+				drops = append(drops, &PlanStep{Description: "drop enum check " + f.PName, SQL: fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", quoteIdent(table), constraint), Destructive: true})
+			}
+			if len(f.PEnumValues) != 0 {
+				adds = append(adds, &PlanStep{Description: "add enum check " + f.PName, SQL: fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", quoteIdent(table), constraint, enumCheckSQL(f))})
+			}
+		}
+	}
+
+	if oldPK, pk := primaryKey(from), primaryKey(target); !slices.Equal(oldPK, pk) {
+		if len(oldPK) != 0 {
+			drops = append(drops, &PlanStep{Description: "drop primary key", SQL: fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", quoteIdent(table), quoteIdent(table+"_pkey")), Destructive: true})
+		}
+		if len(pk) != 0 {
+			adds = append(adds, &PlanStep{Description: "add primary key (" + strings.Join(pk, ", ") + ")", SQL: fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s);", quoteIdent(table), strings.Join(pk, ", "))})
+		}
+	}
+
+	indexSteps := func(kind, prefix, create string, oldSets, sets [][]string) {
+		for _, cols := range oldSets {
+			if !containsColumns(sets, cols) {
+				name := fmt.Sprintf("%s_%s_%s", prefix, target.PName, strings.Join(cols, "_"))
+				drops = append(drops, &PlanStep{Description: "drop " + kind + " (" + strings.Join(cols, ", ") + ")", SQL: fmt.Sprintf("DROP INDEX IF EXISTS %s;", name), Destructive: true})
+			}
+		}
+		for _, cols := range sets {
+			if !containsColumns(oldSets, cols) {
+				name := fmt.Sprintf("%s_%s_%s", prefix, target.PName, strings.Join(cols, "_"))
+				adds = append(adds, &PlanStep{Description: "add " + kind + " (" + strings.Join(cols, ", ") + ")", SQL: fmt.Sprintf("%s %s ON %s (%s);", create, name, quoteIdent(table), strings.Join(cols, ", "))})
+			}
+		}
+	}
+	indexSteps("index", "idx", "CREATE INDEX", from.PIndexes, target.PIndexes)
+	indexSteps("unique index", "uniq", "CREATE UNIQUE INDEX", uniqueIndexes(from), uniqueIndexes(target))
+
+	diff.Steps = slices.Concat(drops, columns, adds)
+	return diff, nil
+}
+
+// columnChanges describes how the column definition of to differs from from.
+func columnChanges(from, to *SchemaField) []string {
+	var changes []string
+	if a, b := columnTypeSQL(from), columnTypeSQL(to); a != b {
+		changes = append(changes, fmt.Sprintf("type %s -> %s", a, b))
+	}
+	if from.PNullable != to.PNullable {
+		changes = append(changes, fmt.Sprintf("nullable %t -> %t", from.PNullable, to.PNullable))
+	}
+	if a, b := normalizeDefault(from.PDefault), normalizeDefault(to.PDefault); a != b && !isSerialType(to.PType) {
+		changes = append(changes, fmt.Sprintf("default %q -> %q", a, b))
+	}
+	return changes
+}
+
+// comparableType folds the types PostgreSQL stores the same way.
+func comparableType(t SchemaFieldType) SchemaFieldType {
+	switch t {
+	case FIELD_SMALL_SERIAL:
+		return FIELD_SMALL_INT
+	case FIELD_SERIAL:
+		return FIELD_INT
+	case FIELD_BIG_SERIAL:
+		return FIELD_BIG_INT
+	case FIELD_FLOAT:
+		return FIELD_DOUBLE
+	case FIELD_FLOAT_ARRAY:
+		return FIELD_DOUBLE_ARRAY
+	}
+	return t
+}
+
+func columnTypeSQL(f *SchemaField) string {
+	t := comparableType(f.PType)
+	if t == FIELD_VARCHAR && f.PMax != nil {
+		return fmt.Sprintf("%s(%d)", t, *f.PMax)
+	}
+	return string(t)
+}
+
+func isSerialType(t SchemaFieldType) bool {
+	return t == FIELD_SMALL_SERIAL || t == FIELD_SERIAL || t == FIELD_BIG_SERIAL
+}
+
+var intTypeRank = map[SchemaFieldType]int{FIELD_SMALL_INT: 1, FIELD_INT: 2, FIELD_BIG_INT: 3}
+
+// isNarrowing reports whether changing the column type of from to the one of to can
+// lose data or fail on the existing rows. Unrelated type changes count as narrowing.
+func isNarrowing(from, to *SchemaField) bool {
+	a, b := comparableType(from.PType), comparableType(to.PType)
+
+	switch {
+	case a == b && a == FIELD_VARCHAR:
+		return to.PMax != nil && (from.PMax == nil || *to.PMax < *from.PMax)
+	case a == b:
+		return false
+	case intTypeRank[a] != 0 && intTypeRank[b] != 0:
+		return intTypeRank[b] < intTypeRank[a]
+	case (a == FIELD_VARCHAR || a == FIELD_UUID) && b == FIELD_TEXT:
+		return false
+	case a == FIELD_TEXT && b == FIELD_VARCHAR && to.PMax == nil:
+		return false
+	}
+	return true
+}
+
+var defaultCastRegex = regexp.MustCompile(`::[a-z][a-z0-9_ ]*(\[\])?`)
+
+// normalizeDefault drops the casts and parentheses PostgreSQL adds to defaults:
+// 'active'::character varying is 'active'.
+func normalizeDefault(def *string) string {
+	if def == nil {
+		return ""
+	}
+	return strings.Trim(defaultCastRegex.ReplaceAllString(*def, ""), "() ")
+}
+
+func sameForeignKey(a, b *ForeignKey) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	rule := func(r ForeignKeyRule) ForeignKeyRule {
+		if r == NO_ACTION {
+			return ""
+		}
+		return r
+	}
+	return a.PSchema == b.PSchema && a.PColumn == b.PColumn && rule(a.POnDelete) == rule(b.POnDelete) && rule(a.POnUpdate) == rule(b.POnUpdate)
+}
+
+func enumValues(f *SchemaField) []string {
+	values := make([]string, len(f.PEnumValues))
+	for i, v := range f.PEnumValues {
+		values[i] = strings.Trim(v, "'")
+	}
+	return values
+}
+
+func primaryKey(s *Schema) []string {
+	if len(s.PCompositePrimaryKey) != 0 {
+		return s.PCompositePrimaryKey
+	}
+
+	var pk []string
+	for _, f := range s.PFields {
+		if f.PPrimaryKey {
+			pk = append(pk, f.PName)
+		}
+	}
+	return pk
+}
+
+// uniqueIndexes merges unique indexes and composite unique keys: both are created as
+// uniq_ indexes and come back from the catalog as unique indexes.
+func uniqueIndexes(s *Schema) [][]string {
+	var sets [][]string
+	for _, cols := range slices.Concat(s.PUniqueIndexes, s.PCompositeUniqueKeys) {
+		if !containsColumns(sets, cols) {
+			sets = append(sets, cols)
+		}
+	}
+	return sets
+}
+
+func containsColumns(sets [][]string, cols []string) bool {
+	return slices.ContainsFunc(sets, func(s []string) bool { return slices.Equal(s, cols) })
+}
+
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
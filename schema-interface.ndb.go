@@ -123,6 +123,25 @@ func (s *Schema) GetField(name string) *SchemaField {
 	return nil
 }
 
+// uniqueKeys lists every column set the table enforces as unique (PK and unique indexes).
+func (s *Schema) uniqueKeys() [][]string {
+	var keys [][]string
+	for _, f := range s.PFields {
+		if f.PPrimaryKey || f.PUnique {
+			keys = append(keys, []string{f.PName})
+		}
+	}
+
+	if len(s.PCompositePrimaryKey) > 0 {
+		keys = append(keys, s.PCompositePrimaryKey)
+	}
+
+	keys = append(keys, s.PUniqueIndexes...)
+	keys = append(keys, s.PCompositeUniqueKeys...)
+
+	return keys
+}
+
 func (s *Schema) AddField(f *SchemaField) *Schema {
 	if s.GetField(f.PName) != nil {
 		s.err = fmt.Errorf("field '%s': already exists and cannot be added", f.PName)
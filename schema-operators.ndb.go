@@ -46,21 +46,11 @@ func (d *DBBridge) generateCreateSchemaSQL(t *Schema) string {
 			line += fmt.Sprintf(" DEFAULT %s", *f.PDefault)
 		}
 		if f.PForeignKey != nil {
-			line += fmt.Sprintf(" REFERENCES \"%s\"(%s)", d.schemaPrefix+f.PForeignKey.PSchema, f.PForeignKey.PColumn)
-			if f.PForeignKey.POnDelete != "" {
-				line += " ON DELETE " + string(f.PForeignKey.POnDelete)
-			}
-			if f.PForeignKey.POnUpdate != "" {
-				line += " ON UPDATE " + string(f.PForeignKey.POnUpdate)
-			}
+			line += " " + d.referencesSQL(f.PForeignKey)
 		}
 
 		if len(f.PEnumValues) > 0 {
-			if strings.Contains(string(f.PType), "[]") {
-				line += fmt.Sprintf(" CHECK (%s <@ ARRAY[%s]::%s)", f.PName, strings.Join(f.PEnumValues, ", "), f.PType)
-			} else {
-				line += fmt.Sprintf(" CHECK (%s IN (%s))", f.PName, strings.Join(f.PEnumValues, ", "))
-			}
+			line += " " + enumCheckSQL(f)
 		}
 		if i < len(t.PFields)-1 {
 			line += ","
@@ -108,6 +98,24 @@ func (d *DBBridge) generateCreateSchemaSQL(t *Schema) string {
 	return sb.String()
 }
 
+func (d *DBBridge) referencesSQL(fk *ForeignKey) string {
+	sql := fmt.Sprintf("REFERENCES \"%s\"(%s)", d.schemaPrefix+fk.PSchema, fk.PColumn)
+	if fk.POnDelete != "" {
+		sql += " ON DELETE " + string(fk.POnDelete)
+	}
+	if fk.POnUpdate != "" {
+		sql += " ON UPDATE " + string(fk.POnUpdate)
+	}
+	return sql
+}
+
+func enumCheckSQL(f *SchemaField) string {
+	if strings.Contains(string(f.PType), "[]") {
+		return fmt.Sprintf("CHECK (%s <@ ARRAY[%s]::%s)", f.PName, strings.Join(f.PEnumValues, ", "), f.PType)
+	}
+	return fmt.Sprintf("CHECK (%s IN (%s))", f.PName, strings.Join(f.PEnumValues, ", "))
+}
+
 func (d *DBBridge) generateDropSchemaSql(name string) string {
 	return fmt.Sprintf("DROP TABLE \"%s\"", d.schemaPrefix+name)
 }
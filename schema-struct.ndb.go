@@ -0,0 +1,273 @@
+package ndb
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var jsonRawType = reflect.TypeOf(json.RawMessage{})
+
+// SchemaFromStruct builds the schema of table name from the exported fields of T.
+// Columns are named after the json tag, or the lowercased field name, like ReadB
+// matches them. The ndb tag refines each column:
+//
+//	Email     string     `json:"email" ndb:"type=VARCHAR,max=254,unique"`
+//	Status    string     `json:"status" ndb:"max=20,default='active',enum='active'|'banned',index"`
+//	OwnerID   int64      `json:"owner_id" ndb:"fk=users.id,on_delete=CASCADE"`
+//	DeletedAt *time.Time `json:"deleted_at"`
+//
+// Keys: type, max, min, pk, unique, nullable, default, pattern, enum (values split
+// by '|'), fk (schema.column), on_delete, on_update, index and unique_index (an
+// optional value groups the columns sharing it into one composite index), comment,
+// display and description. ndb:"-" skips the field. Pointer fields are nullable and
+// the type is inferred from the Go type when the tag has none.
+func SchemaFromStruct[T any](name string) (*Schema, error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("schema '%s': %s is not a struct", name, t)
+	}
+
+	s := NewSchema(name)
+	var (
+		indexes, uniqueIndexes  = map[string][]string{}, map[string][]string{}
+		indexOrder, uniqueOrder []string
+	)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("ndb")
+		if sf.PkgPath != "" || tag == "-" {
+			continue
+		}
+
+		f := s.NewField(columnName(sf))
+		if s.err != nil {
+			return nil, fmt.Errorf("schema '%s': %w", name, s.err)
+		}
+
+		goType := sf.Type
+		if goType.Kind() == reflect.Pointer {
+			f.Nullable()
+			goType = goType.Elem()
+		}
+
+		for _, opt := range splitTagOptions(tag) {
+			key, val, _ := strings.Cut(opt, "=")
+			key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+
+			switch key {
+			case "index":
+				group := groupName(val, f.PName)
+				if _, ok := indexes[group]; !ok {
+					indexOrder = append(indexOrder, group)
+				}
+				indexes[group] = append(indexes[group], f.PName)
+			case "unique_index":
+				group := groupName(val, f.PName)
+				if _, ok := uniqueIndexes[group]; !ok {
+					uniqueOrder = append(uniqueOrder, group)
+				}
+				uniqueIndexes[group] = append(uniqueIndexes[group], f.PName)
+			default:
+				if err := applyFieldTag(f, key, val); err != nil {
+					return nil, fmt.Errorf("schema '%s': field '%s': %w", name, f.PName, err)
+				}
+			}
+		}
+
+		if f.PType == "" {
+			typ, ok := fieldTypeOf(goType)
+			if !ok {
+				return nil, fmt.Errorf("schema '%s': field '%s': cannot infer a type from %s", name, f.PName, sf.Type)
+			}
+			if typ == FIELD_TEXT && f.PMax != nil {
+				typ = FIELD_VARCHAR
+			}
+			f.Type(typ)
+		}
+	}
+
+	for _, group := range indexOrder {
+		s.Indexes(indexes[group]...)
+	}
+	for _, group := range uniqueOrder {
+		s.UniqueIndex(uniqueIndexes[group]...)
+	}
+
+	return s, nil
+}
+
+func columnName(sf reflect.StructField) string {
+	if tag := sf.Tag.Get("json"); tag != "" && tag != "-" {
+		if name := strings.Split(tag, ",")[0]; name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(sf.Name)
+}
+
+func groupName(val, column string) string {
+	if val == "" {
+		return "\x00" + column
+	}
+	return val
+}
+
+// splitTagOptions splits an ndb tag by commas outside parentheses and quotes, so
+// defaults such as now() or 'a,b' and patterns keep their commas.
+func splitTagOptions(tag string) []string {
+	var (
+		opts  []string
+		depth int
+		quote bool
+		start int
+	)
+
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\'':
+			quote = !quote
+		case quote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			if opt := strings.TrimSpace(tag[start:i]); opt != "" {
+				opts = append(opts, opt)
+			}
+			start = i + 1
+		}
+	}
+
+	if opt := strings.TrimSpace(tag[start:]); opt != "" {
+		opts = append(opts, opt)
+	}
+	return opts
+}
+
+func applyFieldTag(f *SchemaField, key, val string) error {
+	switch key {
+	case "type":
+		typ := SchemaFieldType(strings.ToUpper(val))
+		if !isKnownFieldType(typ) {
+			return fmt.Errorf("unknown type %q", val)
+		}
+		f.Type(typ)
+	case "max", "min":
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", key, val)
+		}
+		if key == "max" {
+			f.Max(n)
+		} else {
+			f.Min(n)
+		}
+	case "pk":
+		f.PK()
+	case "unique":
+		f.Unique()
+	case "nullable":
+		f.Nullable()
+	case "default":
+		f.Default(val)
+	case "pattern":
+		f.Pattern(val)
+	case "enum":
+		f.Enum(strings.Split(val, "|")...)
+	case "fk":
+		schema, column, ok := strings.Cut(val, ".")
+		if !ok {
+			return fmt.Errorf("fk must be schema.column, got %q", val)
+		}
+		f.NewFK(schema, column)
+	case "on_delete", "on_update":
+		if f.PForeignKey == nil {
+			return fmt.Errorf("%s needs a preceding fk", key)
+		}
+		rule := ForeignKeyRule(strings.ToUpper(val))
+		if key == "on_delete" {
+			f.PForeignKey.OnDelete(rule)
+		} else {
+			f.PForeignKey.OnUpdate(rule)
+		}
+		return f.PForeignKey.Validate()
+	case "comment":
+		f.PComment = val
+	case "display":
+		f.DisplayName(val)
+	case "description":
+		f.Description(val)
+	default:
+		return fmt.Errorf("unknown ndb tag key %q", key)
+	}
+
+	return nil
+}
+
+func isKnownFieldType(t SchemaFieldType) bool {
+	if _, ok := arrayBaseType[t]; ok {
+		return true
+	}
+
+	switch t {
+	case FIELD_SMALL_INT, FIELD_INT, FIELD_BIG_INT, FIELD_SMALL_SERIAL, FIELD_SERIAL, FIELD_BIG_SERIAL,
+		FIELD_VARCHAR, FIELD_TEXT, FIELD_UUID, FIELD_BOOLEAN, FIELD_TIMESTAMP, FIELD_JSONB, FIELD_FLOAT, FIELD_DOUBLE:
+		return true
+	}
+	return false
+}
+
+// fieldTypeOf infers the column type of a Go type: structs, maps, slices of them and
+// json.RawMessage are stored as JSONB and [16]byte (uuid.UUID) as UUID.
+func fieldTypeOf(t reflect.Type) (SchemaFieldType, bool) {
+	switch {
+	case t == timeType:
+		return FIELD_TIMESTAMP, true
+	case t == jsonRawType:
+		return FIELD_JSONB, true
+	case t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8:
+		return FIELD_UUID, true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return FIELD_TEXT, true
+	case reflect.Bool:
+		return FIELD_BOOLEAN, true
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return FIELD_SMALL_INT, true
+	case reflect.Int32, reflect.Uint16:
+		return FIELD_INT, true
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return FIELD_BIG_INT, true
+	case reflect.Float32:
+		return FIELD_FLOAT, true
+	case reflect.Float64:
+		return FIELD_DOUBLE, true
+	case reflect.Map, reflect.Struct:
+		return FIELD_JSONB, true
+	case reflect.Slice:
+		switch elem := t.Elem(); {
+		case elem.Kind() == reflect.Uint8:
+			return "", false
+		case elem != timeType && (elem.Kind() == reflect.Map || elem.Kind() == reflect.Struct):
+			return FIELD_JSONB, true
+		}
+		base, ok := fieldTypeOf(t.Elem())
+		if !ok {
+			return "", false
+		}
+		for arr, b := range arrayBaseType {
+			if b == base {
+				return arr, true
+			}
+		}
+	}
+
+	return "", false
+}
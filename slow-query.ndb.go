@@ -0,0 +1,140 @@
+package ndb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// SlowQueryRecord is what the slow query log writes for every statement slower than
+// ndb.slow_query.threshold_ms. Args are redacted to their types.
+type SlowQueryRecord struct {
+	At         time.Time     `json:"at"`
+	Schema     string        `json:"schema,omitempty"`
+	SQL        string        `json:"sql"`
+	Args       []string      `json:"args,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Rows       int64         `json:"rows"`
+	Err        string        `json:"error,omitempty"`
+	Plan       *PlanNode     `json:"plan,omitempty"`
+	ExplainErr string        `json:"explain_error,omitempty"`
+}
+
+type SlowQuerySink = func(record SlowQueryRecord)
+
+// ErrExplainBusy is the ExplainErr of the records whose plan was skipped because
+// ndb.slow_query.explain_concurrency captures were already running.
+var ErrExplainBusy = errors.New("slow query plan skipped: too many explains running")
+
+// NewJSONSlowQuerySink writes every record to w as a JSON line.
+func NewJSONSlowQuerySink(w io.Writer) SlowQuerySink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+
+	return func(record SlowQueryRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(record)
+	}
+}
+
+// RedactArgs replaces every arg by its type, so logs never hold user data.
+func RedactArgs(args []any) []string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			out[i] = "<nil>"
+		} else {
+			out[i] = fmt.Sprintf("<%T>", arg)
+		}
+	}
+	return out
+}
+
+// SlowQueryLog returns an AfterExecute hook sending the statements slower than
+// ndb.slow_query.threshold_ms to sink. With ndb.slow_query.explain the plan of the
+// builders' statements is captured with EXPLAIN (FORMAT JSON) and the same args in
+// the background, so the caller does not wait for it: on a replica when the bridge
+// has some, else on its primary pool, within ndb.slow_query.explain_timeout_ms.
+// Those records reach sink from another goroutine, once the plan is captured. At
+// most ndb.slow_query.explain_concurrency plans are captured at once, so a slow
+// database is not flooded with EXPLAINs: past that the record is sent without a
+// plan and ExplainErr set to ErrExplainBusy.
+//
+//	bridge.AfterExecute(bridge.SlowQueryLog(ndb.NewJSONSlowQuerySink(os.Stderr)))
+func (dbb *DBBridge) SlowQueryLog(sink SlowQuerySink) ExecuteHook {
+	slots := make(chan struct{}, max(slowQueryExplainConcurrency, 1))
+
+	return func(e QueryEvent) {
+		if slowQueryThreshold <= 0 || e.Duration < slowQueryThreshold {
+			return
+		}
+
+		record := SlowQueryRecord{
+			At:       time.Now().Add(-e.Duration),
+			SQL:      e.SQL,
+			Args:     RedactArgs(e.Args),
+			Duration: e.Duration,
+			Rows:     e.RowsAffected,
+		}
+
+		if e.Query != nil {
+			record.Schema = e.Query.GetSchemaName()
+		}
+		if e.Err != nil {
+			record.Err = e.Err.Error()
+		}
+
+		if explainer := dbb.slowQueryExplainer(); explainer != nil && e.Err == nil && cacheable(e.SQL) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				record.ExplainErr = ErrExplainBusy.Error()
+				sink(record)
+				return
+			}
+
+			args := slices.Clone(e.Args)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.WithoutCancel(dbb.Context()), slowQueryExplainTimeout)
+				explainer.ctx = ctx
+
+				plan, err := explainer.explainSQL(e.SQL, args...)
+				cancel()
+				<-slots
+
+				if err != nil {
+					record.ExplainErr = err.Error()
+				}
+				record.Plan = plan
+				sink(record)
+			}()
+			return
+		}
+
+		sink(record)
+	}
+}
+
+// slowQueryExplainer returns the bridge slow statements are explained on, outside
+// any transaction, or nil when plans are not captured.
+func (dbb *DBBridge) slowQueryExplainer() *DBBridge {
+	if !slowQueryExplain {
+		return nil
+	}
+
+	explainer := *dbb
+	explainer.trx = nil
+	if dbb.router != nil && len(dbb.router.replicas) != 0 {
+		explainer.db = dbb.router.pick()
+	}
+	if explainer.db == nil {
+		return nil
+	}
+	return &explainer
+}
@@ -41,6 +41,8 @@ const (
 	NOW
 	CURRENT_DATE
 	DATE_TRUNC
+
+	OVER
 )
 
 var funcs = [256](func(name string, v ...string) string){
@@ -82,6 +84,7 @@ var funcs = [256](func(name string, v ...string) string){
 	NOW:          func(name string, v ...string) string { return "NOW()" },
 	CURRENT_DATE: func(name string, v ...string) string { return "CURRENT_DATE" },
 	DATE_TRUNC:   func(name string, v ...string) string { return "DATE_TRUNC(" + name + "," + v[0] + ")" }, // ('day', ts)
+	OVER:         func(name string, v ...string) string { return name + " OVER()" },
 }
 
 func (sf *SQLField) DoneField() *Query {
@@ -147,3 +150,4 @@ func (f *SQLField) Now() *SQLField            { return f.addFunc(NOW) }
 func (f *SQLField) CurrentDate() *SQLField    { return f.addFunc(CURRENT_DATE) }
 func (f *SQLField) DateTrunc() *SQLField      { return f.addFunc(DATE_TRUNC) }
 func (f *SQLField) As(alias string) *SQLField { return f.addFunc(AS, alias) }
+func (f *SQLField) Over() *SQLField           { return f.addFunc(OVER) }
@@ -0,0 +1,179 @@
+package ndb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/lib/pq"
+)
+
+type StmtCacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+type stmtKey struct {
+	db  *sql.DB
+	sql string
+}
+
+// cachedStmt is an LRU entry; evicted is set before the statement is closed, so a
+// call running on it knows its "statement is closed" error came from the eviction.
+type cachedStmt struct {
+	stmt    *sql.Stmt
+	evicted atomic.Bool
+}
+
+// stmtCache is an LRU of prepared statements keyed by pool and generated SQL. It is
+// shared by a bridge, its copies and its transaction bridges.
+type stmtCache struct {
+	lru     *lru.Cache
+	primary *sql.DB
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+func newStmtCache(size int, primary *sql.DB) *stmtCache {
+	cache, err := lru.NewWithEvict(size, func(_, value any) {
+		entry := value.(*cachedStmt)
+		entry.evicted.Store(true)
+		entry.stmt.Close()
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return &stmtCache{lru: cache, primary: primary}
+}
+
+// cacheable keeps the cache to the single DML statements the builders generate:
+// multi-statement DDL and utility commands cannot be prepared.
+func cacheable(query string) bool {
+	if strings.IndexByte(query, ';') != -1 {
+		return false
+	}
+
+	return strings.HasPrefix(query, "SELECT ") || strings.HasPrefix(query, "INSERT ") ||
+		strings.HasPrefix(query, "UPDATE ") || strings.HasPrefix(query, "DELETE ")
+}
+
+func (b *DBBridge) stmtKey(query string) stmtKey {
+	if b.db == nil {
+		return stmtKey{db: b.stmts.primary, sql: query}
+	}
+	return stmtKey{db: b.db, sql: query}
+}
+
+func (b *DBBridge) prepared(query string) (*sql.Stmt, *cachedStmt, error) {
+	key := b.stmtKey(query)
+	if v, ok := b.stmts.lru.Get(key); ok {
+		b.stmts.hits.Add(1)
+		entry := v.(*cachedStmt)
+		return b.txStmt(entry.stmt), entry, nil
+	}
+
+	b.stmts.misses.Add(1)
+	stmt, err := key.db.PrepareContext(b.Context(), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := &cachedStmt{stmt: stmt}
+	if prev, ok, _ := b.stmts.lru.PeekOrAdd(key, entry); ok {
+		stmt.Close()
+		entry = prev.(*cachedStmt)
+	}
+
+	return b.txStmt(entry.stmt), entry, nil
+}
+
+// txStmt binds the cached statement to the bridge transaction, if any; the
+// returned statement is closed by the transaction itself.
+func (b *DBBridge) txStmt(stmt *sql.Stmt) *sql.Stmt {
+	if b.trx == nil {
+		return stmt
+	}
+	return b.trx.StmtContext(b.Context(), stmt)
+}
+
+func (b *DBBridge) useStmtCache(query string) bool {
+	return b.stmts != nil && cacheable(query)
+}
+
+func (b *DBBridge) queryStmt(query string, args ...any) (*sql.Rows, error) {
+	stmt, entry, err := b.prepared(query)
+	if err != nil {
+		// statements are prepared outside the transaction: tables it created are not visible yet
+		if b.trx != nil {
+			return b.queryDirect(query, args...)
+		}
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(b.Context(), args...)
+	if err != nil && b.staleStmt(query, entry, err) {
+		return b.queryDirect(query, args...)
+	}
+	return rows, err
+}
+
+func (b *DBBridge) execStmt(query string, args ...any) (sql.Result, error) {
+	stmt, entry, err := b.prepared(query)
+	if err != nil {
+		if b.trx != nil {
+			return b.execDirect(query, args...)
+		}
+		return nil, err
+	}
+
+	res, err := stmt.ExecContext(b.Context(), args...)
+	if err != nil && b.staleStmt(query, entry, err) {
+		return b.execDirect(query, args...)
+	}
+	return res, err
+}
+
+// staleStmt reports whether a call failing with err on the cached entry of query
+// should be retried once without it. Only two failures are known not to have run
+// the statement: an eviction closing the entry under the call, and a stale plan
+// (0A000) or missing prepared statement (26000) reported by the server, whose entry
+// is evicted; the latter is retried only outside a transaction, which it aborted.
+// Any other error, a broken connection included, may follow a write that already
+// ran and is returned as is.
+func (b *DBBridge) staleStmt(query string, entry *cachedStmt, err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		// a closed statement fails before anything is sent
+		return entry.evicted.Load() && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if pqErr.Code != "0A000" && pqErr.Code != "26000" {
+		return false
+	}
+
+	if v, ok := b.stmts.lru.Peek(b.stmtKey(query)); ok && v.(*cachedStmt) == entry {
+		b.stmts.lru.Remove(b.stmtKey(query))
+	}
+	return b.trx == nil
+}
+
+// InvalidateStatements closes every cached prepared statement. Schema changes made
+// through the bridge call it; call it after altering tables by other means.
+func (b *DBBridge) InvalidateStatements() {
+	if b.stmts != nil {
+		b.stmts.lru.Purge()
+	}
+}
+
+// StatementCacheStats reports the prepared statement cache usage (zero when disabled).
+func (b *DBBridge) StatementCacheStats() StmtCacheStats {
+	if b.stmts == nil {
+		return StmtCacheStats{}
+	}
+
+	return StmtCacheStats{Hits: b.stmts.hits.Load(), Misses: b.stmts.misses.Load(), Size: b.stmts.lru.Len()}
+}
@@ -0,0 +1,93 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestAfterExecuteHooks(t *testing.T) {
+	db, _ := sql.Open("postgres", testDSN)
+	defer db.Close()
+
+	observed := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: bridge.GetSchemaPrefix(), SchemaStorage: schemaStorage})
+
+	var events []ndb.QueryEvent
+	observed.AfterExecute(func(e ndb.QueryEvent) { events = append(events, e) })
+
+	last := func(t *testing.T) ndb.QueryEvent {
+		t.Helper()
+		if len(events) == 0 {
+			t.Fatalf("no_events_recorded")
+		}
+		return events[len(events)-1]
+	}
+
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_create_and_read_report_query", func(t *testing.T) {
+		create := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "hooks@test.com"}).
+			Fields("id")
+		if _, err := observed.CreateOne(create); err != nil {
+			t.Fatalf("create_error: %v", err)
+		}
+
+		e := last(t)
+		if e.Query != create || e.RowsAffected != 1 || e.Err != nil || e.SQL == "" || len(e.Args) == 0 {
+			t.Fatalf("create_event_mismatch event=%+v", e)
+		}
+
+		var users []User
+		read := ndb.NewReadQuery(usersTable.PName).Where(ndb.M{"email": "hooks@test.com"})
+		if err := observed.ReadB(read, &users); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+
+		e = last(t)
+		if e.Query != read || e.RowsAffected != 1 || e.Duration <= 0 {
+			t.Fatalf("read_event_mismatch event=%+v", e)
+		}
+	})
+
+	mustStep(t, "03_exec_and_raw_queries", func(t *testing.T) {
+		update := ndb.NewUpdateQuery(usersTable.PName).
+			Payload(ndb.M{"username": "hooked"}).
+			Where(ndb.M{"email": "hooks@test.com"})
+		if _, err := observed.UpdateWithRowsAffected(update); err != nil {
+			t.Fatalf("update_error: %v", err)
+		}
+		if e := last(t); e.Query != update || e.RowsAffected != 1 {
+			t.Fatalf("update_event_mismatch event=%+v", e)
+		}
+
+		if _, err := observed.ExecuteQuery("SELECT 1"); err != nil {
+			t.Fatalf("raw_query_error: %v", err)
+		}
+		if e := last(t); e.Query != nil || e.SQL != "SELECT 1" || e.RowsAffected != 1 {
+			t.Fatalf("raw_event_mismatch event=%+v", e)
+		}
+	})
+
+	mustStep(t, "04_transactions_and_errors", func(t *testing.T) {
+		before := len(events)
+		err := observed.Transaction(func(tx *ndb.DBBridge) error {
+			_, err := tx.CreateOne(ndb.NewCreateQuery(usersTable.PName).
+				Payload(ndb.M{"public_id": uuid.NewString(), "email": "hooks@test.com"}))
+			return err
+		})
+		if !errors.Is(err, ndb.ErrUniqueViolation) {
+			t.Fatalf("expected unique violation, got: %v", err)
+		}
+
+		e := last(t)
+		if len(events) != before+1 || !errors.Is(e.Err, ndb.ErrUniqueViolation) || e.Query == nil {
+			t.Fatalf("transaction_event_mismatch events=%d event=%+v", len(events)-before, e)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestContextCancellation(t *testing.T) {
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_read_with_live_context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := bridge.WithContext(ctx).Read(ndb.NewReadQuery(usersTable.PName)); err != nil {
+			t.Fatalf("read_with_context_error: %v", err)
+		}
+	})
+
+	mustStep(t, "03_read_with_cancelled_context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := bridge.WithContext(ctx).Read(ndb.NewReadQuery(usersTable.PName))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+	})
+
+	mustStep(t, "04_pg_sleep_deadline_exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if _, err := bridge.WithContext(ctx).ExecuteQuery("SELECT pg_sleep(2)"); err == nil {
+			t.Fatalf("expected deadline error")
+		}
+	})
+
+	mustStep(t, "05_transaction_with_cancelled_context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := bridge.WithContext(ctx).Transaction(func(tx *ndb.DBBridge) error { return nil })
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+	})
+}
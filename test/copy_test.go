@@ -0,0 +1,151 @@
+package test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestCopyFromAndCopyTo(t *testing.T) {
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_copy_from_csv", func(t *testing.T) {
+		in := strings.NewReader(
+			"public_id,email,username\n" +
+				"4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a01,copy_a@test.com,copy_a\n" +
+				"4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a02,copy_b@test.com,\\N\n" +
+				"4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a03,copy_c@test.com,\n",
+		)
+
+		n, err := bridge.CopyFrom(usersTable.PName, in, ndb.COPY_CSV)
+		if err != nil {
+			t.Fatalf("copy_from_csv_error: %v", err)
+		}
+		if n != 3 {
+			t.Fatalf("copy_from_csv_count expected=3 actual=%d", n)
+		}
+	})
+
+	mustStep(t, "03_copy_from_ndjson", func(t *testing.T) {
+		in := strings.NewReader(
+			`{"user_id":1,"amount":10.5,"arr_field":[0,1]}` + "\n" +
+				`{"user_id":1,"amount":4,"arr_field":[2]}` + "\n",
+		)
+
+		n, err := bridge.CopyFrom(userPayments.PName, in, ndb.COPY_NDJSON)
+		if err != nil {
+			t.Fatalf("copy_from_ndjson_error: %v", err)
+		}
+		if n != 2 {
+			t.Fatalf("copy_from_ndjson_count expected=2 actual=%d", n)
+		}
+	})
+
+	mustStep(t, "04_copy_from_rejects_invalid_record", func(t *testing.T) {
+		in := strings.NewReader("public_id,email\nnot-used," + strings.Repeat("x", 300) + "\n")
+
+		if _, err := bridge.CopyFrom(usersTable.PName, in, ndb.COPY_CSV); err == nil {
+			t.Fatalf("expected validation error")
+		}
+	})
+
+	mustStep(t, "05_copy_to_csv", func(t *testing.T) {
+		var out bytes.Buffer
+		q := ndb.NewReadQuery(usersTable.PName).
+			Fields("id", "email", "username").
+			Order(ndb.Fs("users.id", "ASC"))
+
+		n, err := bridge.CopyTo(q, &out, ndb.COPY_CSV)
+		if err != nil {
+			t.Fatalf("copy_to_csv_error: %v", err)
+		}
+
+		records, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("copy_to_csv_parse_error: %v", err)
+		}
+		if n != 3 || len(records) != 4 || records[1][1] != "copy_a@test.com" || records[2][2] != ndb.CopyCSVNull || records[3][2] != "" {
+			t.Fatalf("copy_to_csv_mismatch n=%d records=%v", n, records)
+		}
+	})
+
+	mustStep(t, "06_copy_to_exports_past_default_limit", func(t *testing.T) {
+		payloads := make([]ndb.M, 0, 150)
+		for i := range 150 {
+			payloads = append(payloads, ndb.M{"public_id": uuid.NewString(), "email": "copy_bulk_" + strconv.Itoa(i) + "@test.com"})
+		}
+		if _, err := bridge.CreateMany(ndb.NewCreateQuery(usersTable.PName).Payloads(payloads).Fields("id")); err != nil {
+			t.Fatalf("seed_error: %v", err)
+		}
+
+		var out bytes.Buffer
+		n, err := bridge.CopyTo(ndb.NewReadQuery(usersTable.PName).Fields("id"), &out, ndb.COPY_CSV)
+		if err != nil || n != 153 {
+			t.Fatalf("copy_to_unbounded_mismatch n=%d err=%v", n, err)
+		}
+
+		out.Reset()
+		limited := ndb.NewReadQuery(usersTable.PName).Fields("id").Limit(10)
+		if n, err := bridge.CopyTo(limited, &out, ndb.COPY_CSV); err != nil || n != 10 {
+			t.Fatalf("copy_to_limit_mismatch n=%d err=%v", n, err)
+		}
+		if limited.IsUnbounded() {
+			t.Fatalf("copy_to_left_query_unbounded")
+		}
+	})
+
+	mustStep(t, "07_copy_to_ndjson", func(t *testing.T) {
+		var out bytes.Buffer
+		q := ndb.NewReadQuery(userPayments.PName).Fields("amount", "arr_field")
+
+		n, err := bridge.CopyTo(q, &out, ndb.COPY_NDJSON)
+		if err != nil {
+			t.Fatalf("copy_to_ndjson_error: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if n != 2 || len(lines) != 2 || !strings.HasPrefix(lines[0], `{"amount":`) {
+			t.Fatalf("copy_to_ndjson_mismatch n=%d out=%s", n, out.String())
+		}
+	})
+}
+
+// runs without a database: the CSV header must survive the records read after it
+func TestCopyFromCSVDryRun(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+	if err := preview.CreateSchema(detached(usersTable)); err != nil {
+		t.Fatalf("create_schema_error: %v", err)
+	}
+	dry.Reset()
+
+	in := strings.NewReader(
+		"public_id,email,username\n" +
+			"4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a01,a@test.com,alice\n" +
+			"4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a02,b@test.com,\\N\n",
+	)
+
+	n, err := preview.CopyFrom(usersTable.PName, in, ndb.COPY_CSV)
+	if err != nil || n != 2 {
+		t.Fatalf("copy_from_csv n=%d err=%v", n, err)
+	}
+
+	// BEGIN, one exec per record, the final flush and COMMIT
+	stmts := dry.Statements()
+	if len(stmts) != 5 || !strings.HasPrefix(stmts[1].SQL, "COPY") {
+		t.Fatalf("statements_mismatch stmts=%+v", stmts)
+	}
+	first, second := stmts[1].Args, stmts[2].Args
+	if len(first) != 3 || first[1] != "a@test.com" || first[2] != "alice" || second[1] != "b@test.com" || second[2] != nil {
+		t.Fatalf("records_mismatch first=%v second=%v", first, second)
+	}
+}
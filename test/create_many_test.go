@@ -0,0 +1,139 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestCreateMany(t *testing.T) {
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_create_many_returns_every_row", func(t *testing.T) {
+		payloads := make([]ndb.M, 0, 50)
+		for i := range 50 {
+			p := ndb.M{
+				"public_id": uuid.NewString(),
+				"email":     "many_" + strconv.Itoa(i) + "@test.com",
+			}
+			if i%2 == 0 {
+				p["username"] = "many_" + strconv.Itoa(i)
+			}
+			payloads = append(payloads, p)
+		}
+
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payloads(payloads).
+			Fields("id", "email", "username", "status")
+
+		rows, err := bridge.CreateMany(q)
+		if err != nil {
+			t.Fatalf("create_many_error: %v", err)
+		}
+		if len(rows) != len(payloads) {
+			t.Fatalf("create_many_len_mismatch expected=%d actual=%d", len(payloads), len(rows))
+		}
+
+		// RETURNING order is not guaranteed: rows are matched on email
+		emails := map[any]bool{}
+		for _, row := range rows {
+			emails[row["email"]] = true
+			if row["status"] != "active" {
+				t.Fatalf("create_many_default_not_applied row=%v", row)
+			}
+		}
+		for _, p := range payloads {
+			if !emails[p["email"]] {
+				t.Fatalf("create_many_row_missing email=%v", p["email"])
+			}
+		}
+	})
+
+	mustStep(t, "03_create_many_b", func(t *testing.T) {
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payloads([]ndb.M{
+				{"public_id": uuid.NewString(), "email": "many_b1@test.com"},
+				{"public_id": uuid.NewString(), "email": "many_b2@test.com"},
+			}).
+			Fields("id", "email")
+
+		var users []User
+		if err := bridge.CreateManyB(q, &users); err != nil {
+			t.Fatalf("create_many_b_error: %v", err)
+		}
+		if len(users) != 2 || users[0].ID == 0 || users[1].ID == 0 {
+			t.Fatalf("create_many_b_invalid: %+v", users)
+		}
+	})
+
+	mustStep(t, "04_conflicts_drop_rows", func(t *testing.T) {
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payloads([]ndb.M{
+				{"public_id": uuid.NewString(), "email": "many_0@test.com"},
+				{"public_id": uuid.NewString(), "email": "many_new@test.com"},
+				{"public_id": uuid.NewString(), "email": "many_1@test.com"},
+			}).
+			OnConflict("email").DoNothing().DoneConflict().
+			Fields("id", "email")
+
+		rows, err := bridge.CreateMany(q)
+		if err != nil {
+			t.Fatalf("create_many_conflict_error: %v", err)
+		}
+		if len(rows) != 1 || rows[0]["email"] != "many_new@test.com" {
+			t.Fatalf("conflicting rows returned rows=%v", rows)
+		}
+	})
+
+	mustStep(t, "05_create_many_validates_every_row", func(t *testing.T) {
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payloads([]ndb.M{
+				{"public_id": uuid.NewString(), "email": "valid_row@test.com"},
+				{"public_id": uuid.NewString()},
+			})
+
+		if _, err := bridge.CreateMany(q); err == nil {
+			t.Fatalf("expected validation error for missing email")
+		}
+	})
+}
+
+// runs without a database: a full chunk plus the conflict filter args must fit the
+// 65535 bind parameters of a statement
+func TestCreateManyChunkBoundary(t *testing.T) {
+	db, _ := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+	if err := preview.CreateSchema(detached(usersTable)); err != nil {
+		t.Fatalf("create_schema_error: %v", err)
+	}
+
+	// 3 columns: 65535/3 rows fill a statement before the 2 WHERE args are added
+	const rows = 65535 / 3
+	payloads := make([]ndb.M, rows)
+	for i := range payloads {
+		payloads[i] = ndb.M{"public_id": uuid.NewString(), "email": "chunk_" + strconv.Itoa(i) + "@test.com", "username": "chunk_" + strconv.Itoa(i)}
+	}
+
+	q := ndb.NewCreateQuery(usersTable.PName).
+		Payloads(payloads).
+		OnConflict("email").DoUpdate("username").Where(ndb.M{"status": ndb.M{"in": []any{"active", "pending"}}}).DoneConflict()
+
+	queries, chunks, err := preview.BuildCreateManyQuery(q)
+	if err != nil {
+		t.Fatalf("build_error: %v", err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("expected 2 chunks, got: %d", len(queries))
+	}
+	for i, args := range chunks {
+		if len(args) > 65535 {
+			t.Fatalf("chunk %d has %d args", i, len(args))
+		}
+	}
+}
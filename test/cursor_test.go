@@ -0,0 +1,118 @@
+package test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestKeysetPagination(t *testing.T) {
+	var pages []*ndb.CursorPage
+
+	newQuery := func() *ndb.Query {
+		return ndb.NewReadQuery(usersTable.PName).
+			Fields("id", "email").
+			Order(ndb.Fs("users.id", "DESC")).
+			Limit(10)
+	}
+
+	mustStep(t, "01_reset_and_seed", func(t *testing.T) {
+		resetSchemas(t)
+
+		payloads := make([]ndb.M, 0, 25)
+		for i := range 25 {
+			payloads = append(payloads, ndb.M{"public_id": uuid.NewString(), "email": "cursor_" + strconv.Itoa(i) + "@test.com"})
+		}
+		if _, err := bridge.CreateMany(ndb.NewCreateQuery(usersTable.PName).Payloads(payloads)); err != nil {
+			t.Fatalf("seed_error: %v", err)
+		}
+	})
+
+	mustStep(t, "02_walk_forward", func(t *testing.T) {
+		page, err := bridge.ReadKeyset(newQuery())
+		for err == nil {
+			pages = append(pages, page)
+			if page.Next == "" {
+				break
+			}
+			page, err = bridge.ReadKeyset(newQuery().After(page.Next))
+		}
+		if err != nil {
+			t.Fatalf("read_keyset_error: %v", err)
+		}
+
+		if len(pages) != 3 || len(pages[0].Items) != 10 || len(pages[2].Items) != 5 {
+			t.Fatalf("forward_pages_mismatch pages=%d", len(pages))
+		}
+		if pages[0].Prev != "" || pages[1].Prev == "" {
+			t.Fatalf("prev_cursor_mismatch")
+		}
+		if pages[0].Items[0]["id"].(int64) != 25 || pages[1].Items[0]["id"].(int64) != 15 {
+			t.Fatalf("forward_order_mismatch first=%v second=%v", pages[0].Items[0], pages[1].Items[0])
+		}
+	})
+
+	mustStep(t, "03_walk_backward", func(t *testing.T) {
+		page, err := bridge.ReadKeyset(newQuery().Before(pages[2].Prev))
+		if err != nil {
+			t.Fatalf("read_keyset_before_error: %v", err)
+		}
+		if len(page.Items) != 10 || page.Items[0]["id"] != pages[1].Items[0]["id"] || page.Next == "" || page.Prev == "" {
+			t.Fatalf("backward_page_mismatch: %+v", page)
+		}
+	})
+
+	mustStep(t, "04_rest_cursor_param", func(t *testing.T) {
+		q, err := ndb.NewQueryFromURIParams(usersTable.PName, "GET", map[string][]string{
+			"f": {"id,email"},
+			"l": {"10"},
+			"c": {pages[0].Next},
+		})
+		if err != nil {
+			t.Fatalf("uri_params_error: %v", err)
+		}
+
+		page, err := bridge.ReadKeyset(q.Order(ndb.Fs("users.id", "DESC")))
+		if err != nil {
+			t.Fatalf("rest_keyset_error: %v", err)
+		}
+		if page.Items[0]["id"] != pages[1].Items[0]["id"] {
+			t.Fatalf("rest_cursor_mismatch: %v", page.Items[0])
+		}
+	})
+
+	mustStep(t, "05_tampered_cursor_rejected", func(t *testing.T) {
+		token := []byte(pages[0].Next)
+		token[0] ^= 1
+
+		if _, err := bridge.ReadKeyset(newQuery().After(string(token))); !errors.Is(err, ndb.ErrInvalidCursor) {
+			t.Fatalf("expected ErrInvalidCursor, got: %v", err)
+		}
+	})
+}
+
+// runs without a database: cursors are neither signed nor verified without a secret
+func TestKeysetWithoutSecret(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+	if err := preview.CreateSchema(detached(usersTable)); err != nil {
+		t.Fatalf("create_schema_error: %v", err)
+	}
+
+	q := func() *ndb.Query {
+		return ndb.NewReadQuery(usersTable.PName).Fields("id").Order(ndb.Fs("users.id", "DESC")).Limit(1)
+	}
+
+	dry.SetRows(ndb.M{"id": int64(1)}, ndb.M{"id": int64(2)})
+	if _, err := preview.ReadKeyset(q()); !errors.Is(err, ndb.ErrNoCursorSecret) {
+		t.Fatalf("expected ErrNoCursorSecret signing, got: %v", err)
+	}
+	if _, err := preview.ReadKeyset(q().After("e30.c2ln")); !errors.Is(err, ndb.ErrNoCursorSecret) {
+		t.Fatalf("expected ErrNoCursorSecret verifying, got: %v", err)
+	}
+}
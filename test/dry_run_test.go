@@ -0,0 +1,111 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+	"github.com/nitsugaro/go-nstore"
+)
+
+// tempStorage returns an empty schema storage in a temp folder: dry-run bridges
+// must not touch the shared storage, whose schemas back the live tables.
+func tempStorage(t *testing.T) *nstore.NStorage[*ndb.Schema] {
+	t.Helper()
+
+	storage, err := nstore.New[*ndb.Schema](t.TempDir())
+	if err != nil {
+		t.Fatalf("temp_storage_error: %v", err)
+	}
+	return storage
+}
+
+// detached copies s without its storage metadata, so saving the copy elsewhere
+// leaves the metadata of the shared schema untouched.
+func detached(s *ndb.Schema) *ndb.Schema {
+	c := ndb.Ptr(*s)
+	c.Metadata = nil
+	return c
+}
+
+// runs without a database: the dry-run bridge only records the statements
+func TestDryRunBridge(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+
+	mustStep(t, "01_create_schema_records_ddl", func(t *testing.T) {
+		if err := preview.CreateSchema(detached(usersTable)); err != nil {
+			t.Fatalf("create_schema_error: %v", err)
+		}
+		stmts := dry.Statements()
+		// BEGIN, the DDL, the history lock, table and row, COMMIT
+		if len(stmts) != 6 || !strings.Contains(stmts[1].SQL, `CREATE TABLE "ndb_users"`) ||
+			!strings.HasPrefix(stmts[4].SQL, `INSERT INTO "`+ndb.MigrationsTable+`"`) || stmts[4].Args[0] != "create users" || stmts[5].SQL != "COMMIT" {
+			t.Fatalf("create_schema_statements_mismatch stmts=%+v", stmts)
+		}
+	})
+
+	mustStep(t, "02_read_records_sql_and_returns_canned_rows", func(t *testing.T) {
+		dry.Reset()
+		dry.SetRows(ndb.M{"id": 7, "email": "dry@test.com"})
+
+		q := ndb.NewReadQuery(usersTable.PName).
+			Fields("id", "email").
+			Where(ndb.M{"email": "dry@test.com"}).
+			Limit(1)
+
+		var users []User
+		if err := preview.ReadB(q, &users); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+		if len(users) != 1 || users[0].ID != 7 || users[0].Email != "dry@test.com" {
+			t.Fatalf("canned_rows_mismatch users=%+v", users)
+		}
+
+		stmt := dry.Last()
+		if stmt.SQL != `SELECT "id","email" FROM "ndb_users" WHERE ("email" = $1) LIMIT 1` {
+			t.Fatalf("read_sql_mismatch sql=%q", stmt.SQL)
+		}
+		if len(stmt.Args) != 1 || stmt.Args[0] != "dry@test.com" {
+			t.Fatalf("read_args_mismatch args=%v", stmt.Args)
+		}
+	})
+
+	mustStep(t, "03_transactions_and_validation", func(t *testing.T) {
+		dry.Reset()
+		dry.Respond(func(stmt ndb.DryRunStatement) []ndb.M {
+			if strings.HasPrefix(stmt.SQL, "INSERT") {
+				return []ndb.M{{"id": 1}}
+			}
+			return nil
+		})
+
+		err := preview.Transaction(func(tx *ndb.DBBridge) error {
+			_, err := tx.CreateOne(ndb.NewCreateQuery(usersTable.PName).
+				Payload(ndb.M{"public_id": uuid.NewString(), "email": "tx@test.com"}).
+				Fields("id"))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("transaction_error: %v", err)
+		}
+
+		stmts := dry.Statements()
+		if len(stmts) != 3 || stmts[0].SQL != "BEGIN" || !strings.HasPrefix(stmts[1].SQL, `INSERT INTO "ndb_users"`) || stmts[2].SQL != "COMMIT" {
+			t.Fatalf("transaction_statements_mismatch stmts=%+v", stmts)
+		}
+
+		dry.Reset()
+		_, err = preview.CreateOne(ndb.NewCreateQuery(usersTable.PName).Payload(ndb.M{"email": 5}))
+		if err == nil || errors.Is(err, ndb.ErrEmptyCreateData) {
+			t.Fatalf("expected validation error, got: %v", err)
+		}
+		if len(dry.Statements()) != 0 {
+			t.Fatalf("invalid_payload_reached_driver")
+		}
+	})
+}
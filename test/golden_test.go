@@ -0,0 +1,85 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+	"github.com/nitsugaro/go-ndb/ndbtest"
+)
+
+// runs without a database; go test -run TestGoldenSQL -update rewrites testdata/golden
+func TestGoldenSQL(t *testing.T) {
+	db, _ := ndb.NewDryRunDB()
+	defer db.Close()
+
+	golden := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+
+	mustStep(t, "01_register_schemas", func(t *testing.T) {
+		if err := golden.CreateSchema(detached(usersTable)); err != nil {
+			t.Fatalf("create_schema_users: %v", err)
+		}
+		if err := golden.CreateSchema(detached(userPayments)); err != nil {
+			t.Fatalf("create_schema_user_payments: %v", err)
+		}
+	})
+
+	mustStep(t, "02_builder_cases", func(t *testing.T) {
+		publicID := "6f1c2a4e-9d3b-4c1a-8e55-2b7f0d9a1c33"
+
+		ndbtest.Run(t, golden, []ndbtest.Case{
+			{
+				Name: "read_where_groups",
+				Query: ndb.NewReadQuery(usersTable.PName).
+					Fields("id", "email", "username").
+					Where(
+						ndb.M{"status": "active", "email": ndb.M{"ilike": "%@test.com"}, "id": ndb.M{"in": []any{1, 2, 3}}},
+						ndb.M{"not": ndb.M{"username": "root", "created_at": ndb.M{"isnull": true}}},
+					).
+					Order(ndb.Fs("users.id", "DESC")).
+					Limit(20).
+					Offset(40),
+			},
+			{
+				Name: "read_join_aggregate",
+				Query: ndb.NewReadQuery(usersTable.PName).
+					NewField("users.id").As("user_id").DoneField().
+					NewField("user_payments.amount").Sum().As("total").DoneField().
+					NewJoin(userPayments.PName, ndb.LEFT_JOIN).On(ndb.M{"user_payments.user_id": ndb.M{"eq_field": "users.id"}}).DoneJoin().
+					Group(ndb.Fs("users.id")),
+			},
+			{
+				Name: "create_payload",
+				Query: ndb.NewCreateQuery(usersTable.PName).
+					Payload(ndb.M{"username": "golden", "public_id": publicID, "email": "golden@test.com", "status": "active"}).
+					Fields("id"),
+			},
+			{
+				Name: "create_upsert_where",
+				Query: ndb.NewCreateQuery(usersTable.PName).
+					Payload(ndb.M{"public_id": publicID, "email": "golden@test.com", "username": "golden"}).
+					OnConflict("email").DoUpdate("username").Where(ndb.M{"status": "active", "not": ndb.M{"username": ndb.M{"like": "root%"}}}).DoneConflict().
+					Fields("id"),
+			},
+			{
+				Name: "update_payload",
+				Query: ndb.NewUpdateQuery(usersTable.PName).
+					Payload(ndb.M{"username": "renamed", "status": "blocked", "email": "renamed@test.com"}).
+					Where(ndb.M{"public_id": publicID}).
+					Fields("id", "status"),
+			},
+			{
+				Name: "delete_where",
+				Query: ndb.NewDeleteQuery(userPayments.PName).
+					Where(ndb.M{"user_id": 1, "amount": ndb.M{"lt": 10}}),
+			},
+		})
+	})
+
+	mustStep(t, "03_json_cases", func(t *testing.T) {
+		cases, err := ndbtest.LoadCases("testdata/golden_queries.json")
+		if err != nil {
+			t.Fatalf("load_cases_error: %v", err)
+		}
+		ndbtest.Run(t, golden, cases)
+	})
+}
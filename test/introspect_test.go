@@ -0,0 +1,98 @@
+package test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestIntrospect(t *testing.T) {
+	var users, payments *ndb.Schema
+
+	mustStep(t, "01_reset_and_introspect", func(t *testing.T) {
+		resetSchemas(t)
+
+		var err error
+		if users, err = bridge.IntrospectTable(usersTable.PName); err != nil {
+			t.Fatalf("introspect_users_error: %v", err)
+		}
+		if payments, err = bridge.IntrospectTable(userPayments.PName); err != nil {
+			t.Fatalf("introspect_payments_error: %v", err)
+		}
+	})
+
+	mustStep(t, "02_columns", func(t *testing.T) {
+		if users.PName != "users" || users.PComment != "User Table" || len(users.PFields) != len(usersTable.PFields) {
+			t.Fatalf("users_schema_mismatch name=%s comment=%q fields=%d", users.PName, users.PComment, len(users.PFields))
+		}
+
+		id := users.GetField("id")
+		if id.PType != ndb.FIELD_BIG_SERIAL || !id.PPrimaryKey || id.PDefault != nil {
+			t.Fatalf("id_field_mismatch field=%+v", id)
+		}
+
+		email := users.GetField("email")
+		if email.PType != ndb.FIELD_VARCHAR || *email.PMax != 254 || !email.PUnique || email.PNullable {
+			t.Fatalf("email_field_mismatch field=%+v", email)
+		}
+
+		if !users.GetField("username").PNullable {
+			t.Fatalf("username_should_be_nullable")
+		}
+		if status := users.GetField("status"); status.PDefault == nil || !strings.HasPrefix(*status.PDefault, "'active'") {
+			t.Fatalf("status_default_mismatch field=%+v", status)
+		}
+		if created := users.GetField("created_at"); created.PType != ndb.FIELD_TIMESTAMP || *created.PDefault != "now()" {
+			t.Fatalf("created_at_mismatch field=%+v", created)
+		}
+	})
+
+	mustStep(t, "03_indexes_fks_and_enums", func(t *testing.T) {
+		if !slices.ContainsFunc(users.PIndexes, func(idx []string) bool { return slices.Equal(idx, []string{"email", "username"}) }) {
+			t.Fatalf("users_indexes_mismatch indexes=%v", users.PIndexes)
+		}
+		if !slices.ContainsFunc(users.PUniqueIndexes, func(idx []string) bool { return slices.Equal(idx, []string{"public_id"}) }) {
+			t.Fatalf("users_unique_indexes_mismatch indexes=%v", users.PUniqueIndexes)
+		}
+
+		fk := payments.GetField("user_id").PForeignKey
+		if fk == nil || fk.PSchema != "users" || fk.PColumn != "id" || fk.POnDelete != ndb.CASCADE {
+			t.Fatalf("fk_mismatch fk=%+v", fk)
+		}
+
+		arr := payments.GetField("arr_field")
+		if arr.PType != ndb.FIELD_SMALL_INT_ARRAY || !slices.Equal(arr.PEnumValues, []string{"0", "1", "2"}) {
+			t.Fatalf("arr_field_mismatch field=%+v", arr)
+		}
+		if amount := payments.GetField("amount"); amount.PType != ndb.FIELD_DOUBLE {
+			t.Fatalf("amount_type_mismatch type=%s", amount.PType)
+		}
+	})
+
+	mustStep(t, "04_introspect_all_and_import", func(t *testing.T) {
+		schemas, err := bridge.IntrospectAll("user")
+		if err != nil {
+			t.Fatalf("introspect_all_error: %v", err)
+		}
+
+		names := make([]string, len(schemas))
+		for i, s := range schemas {
+			names[i] = s.PName
+		}
+		for _, name := range []string{"user_payments", "users", "users_type"} {
+			if !slices.Contains(names, name) {
+				t.Fatalf("introspect_all_missing name=%s names=%v", name, names)
+			}
+		}
+
+		if err := bridge.ImportSchema(users); err == nil {
+			t.Fatalf("expected already exists error")
+		}
+
+		if _, err := bridge.IntrospectTable("missing_table"); err == nil {
+			t.Fatalf("expected not found error")
+		}
+	})
+}
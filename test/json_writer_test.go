@@ -0,0 +1,71 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestJSONWriter(t *testing.T) {
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_create_json_array", func(t *testing.T) {
+		var out bytes.Buffer
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "json_a@test.com"}).
+			Fields("id", "email")
+
+		n, err := bridge.CreateJSON(q, &out, false)
+		if err != nil {
+			t.Fatalf("create_json_error: %v", err)
+		}
+
+		var users []User
+		if err := json.Unmarshal(out.Bytes(), &users); err != nil {
+			t.Fatalf("create_json_decode_error: %v out=%s", err, out.String())
+		}
+		if n != 1 || len(users) != 1 || users[0].Email != "json_a@test.com" {
+			t.Fatalf("create_json_mismatch n=%d users=%+v", n, users)
+		}
+	})
+
+	mustStep(t, "03_read_json_ndjson", func(t *testing.T) {
+		if _, err := bridge.Create(ndb.NewCreateQuery(usersTable.PName).Payload(ndb.M{"public_id": uuid.NewString(), "email": "json_b@test.com"})); err != nil {
+			t.Fatalf("seed_error: %v", err)
+		}
+
+		var out bytes.Buffer
+		n, err := bridge.ReadJSON(ndb.NewReadQuery(usersTable.PName).Fields("id", "email"), &out, true)
+		if err != nil {
+			t.Fatalf("read_json_error: %v", err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if n != 2 || len(lines) != 2 {
+			t.Fatalf("read_json_ndjson_mismatch n=%d out=%s", n, out.String())
+		}
+		for _, line := range lines {
+			if !json.Valid([]byte(line)) {
+				t.Fatalf("invalid_ndjson_line: %s", line)
+			}
+		}
+	})
+
+	mustStep(t, "04_empty_result_is_empty_array", func(t *testing.T) {
+		var out bytes.Buffer
+		q := ndb.NewDeleteQuery(usersTable.PName).Where(ndb.M{"email": "missing@test.com"}).Fields("id")
+
+		if _, err := bridge.DeleteJSON(q, &out, false); err != nil {
+			t.Fatalf("delete_json_error: %v", err)
+		}
+		if out.String() != "[]" {
+			t.Fatalf("expected empty array, got: %s", out.String())
+		}
+	})
+}
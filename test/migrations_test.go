@@ -0,0 +1,298 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+func noteMigrations() []*ndb.Migration {
+	body := &ndb.SchemaField{PName: "body", PType: ndb.FIELD_TEXT, PNullable: true}
+
+	return []*ndb.Migration{
+		{
+			Version: 1,
+			Name:    "create_notes",
+			Up: func(tx *ndb.DBBridge) error {
+				return tx.CreateSchema(ndb.NewSchema("mig_notes").
+					NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField().
+					NewField("title").Type(ndb.FIELD_VARCHAR).Max(100).DoneField())
+			},
+			Down: func(tx *ndb.DBBridge) error { return tx.DeleteSchema("mig_notes") },
+		},
+		{
+			Version: 2,
+			Name:    "add_notes_body",
+			Up: func(tx *ndb.DBBridge) error {
+				return tx.ModifySchema("mig_notes", []*ndb.AlterField{{Field: body, AlterAction: ndb.ADD_COLUMN}})
+			},
+			Down: func(tx *ndb.DBBridge) error {
+				return tx.ModifySchema("mig_notes", []*ndb.AlterField{{Field: body, AlterAction: ndb.DROP_COLUMN}})
+			},
+		},
+		{
+			Version: 3,
+			Name:    "seed_notes",
+			Up: func(tx *ndb.DBBridge) error {
+				_, err := tx.ExecuteQuery(`INSERT INTO "ndb_mig_notes" (title) VALUES ('welcome')`)
+				return err
+			},
+			Down: func(tx *ndb.DBBridge) error {
+				_, err := tx.ExecuteQuery(`DELETE FROM "ndb_mig_notes"`)
+				return err
+			},
+		},
+	}
+}
+
+func appliedVersions(t *testing.T, b *ndb.DBBridge) []int64 {
+	t.Helper()
+
+	applied, err := b.AppliedMigrations()
+	if err != nil {
+		t.Fatalf("applied_migrations_error: %v", err)
+	}
+
+	versions := make([]int64, len(applied))
+	for i, m := range applied {
+		versions[i] = m.Version
+	}
+	return versions
+}
+
+func TestMigrate(t *testing.T) {
+	mb := bridge.WithContext(context.Background())
+	mb.AddMigrations(noteMigrations()...)
+
+	mustStep(t, "01_reset", func(t *testing.T) {
+		bridge.DeleteSchema("mig_notes")
+		if _, err := bridge.ExecuteQuery(`DROP TABLE IF EXISTS "` + ndb.MigrationsTable + `"`); err != nil {
+			t.Fatalf("drop_migrations_error: %v", err)
+		}
+		if versions := appliedVersions(t, mb); len(versions) != 0 {
+			t.Fatalf("expected no history, got: %v", versions)
+		}
+	})
+
+	mustStep(t, "02_concurrent_up", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = mb.Migrate(ndb.LatestMigration)
+			}()
+		}
+		wg.Wait()
+
+		if err := errors.Join(errs...); err != nil {
+			t.Fatalf("migrate_error: %v", err)
+		}
+
+		rows, err := bridge.ExecuteQuery(`SELECT count(*) AS n FROM "ndb_mig_notes"`)
+		if err != nil || rows[0]["n"] != int64(1) {
+			t.Fatalf("seed_mismatch rows=%v err=%v", rows, err)
+		}
+	})
+
+	mustStep(t, "03_history", func(t *testing.T) {
+		applied, err := mb.AppliedMigrations()
+		if err != nil || len(applied) != 3 {
+			t.Fatalf("history_mismatch applied=%d err=%v", len(applied), err)
+		}
+
+		create, alter := applied[0], applied[1]
+		if len(create.Checksum) != 64 || len(create.Changes) != 1 || create.Changes[0].Action != "create" {
+			t.Fatalf("create_entry_mismatch entry=%+v", create)
+		}
+		if len(alter.Changes) != 1 || len(alter.Changes[0].Fields) != 1 || alter.Changes[0].Fields[0].Field.PName != "body" {
+			t.Fatalf("alter_entry_mismatch entry=%+v", alter)
+		}
+		if len(applied[2].Changes) != 0 {
+			t.Fatalf("seed_entry_mismatch entry=%+v", applied[2])
+		}
+
+		if s, ok := bridge.GetSchemaByName("mig_notes"); !ok || s.GetField("body") == nil {
+			t.Fatalf("stored_schema_mismatch ok=%t", ok)
+		}
+	})
+
+	mustStep(t, "04_direct_operations_recorded", func(t *testing.T) {
+		direct := ndb.NewSchema("mig_direct").NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField()
+		if err := bridge.CreateSchema(direct); err != nil {
+			t.Fatalf("create_schema_error: %v", err)
+		}
+		if err := bridge.DeleteSchema("mig_direct"); err != nil {
+			t.Fatalf("delete_schema_error: %v", err)
+		}
+
+		history, err := bridge.SchemaHistory()
+		if err != nil || len(history) < 2 {
+			t.Fatalf("history_error rows=%d err=%v", len(history), err)
+		}
+		created, dropped := history[len(history)-2], history[len(history)-1]
+		if created.Version != 0 || created.Name != "create mig_direct" || len(created.Checksum) != 64 || dropped.Name != "drop mig_direct" {
+			t.Fatalf("direct_rows_mismatch created=%+v dropped=%+v", created, dropped)
+		}
+		if versions := appliedVersions(t, mb); len(versions) != 3 {
+			t.Fatalf("direct rows listed as migrations versions=%v", versions)
+		}
+	})
+
+	mustStep(t, "05_edited_migration_fails_checksum", func(t *testing.T) {
+		migrations := noteMigrations()
+		migrations[1].Up = func(tx *ndb.DBBridge) error {
+			body := &ndb.SchemaField{PName: "body", PType: ndb.FIELD_VARCHAR, PMax: ndb.Ptr(500), PNullable: true}
+			return tx.ModifySchema("mig_notes", []*ndb.AlterField{{Field: body, AlterAction: ndb.ADD_COLUMN}})
+		}
+
+		edited := bridge.WithContext(context.Background())
+		edited.AddMigrations(migrations...)
+		if err := edited.Migrate(ndb.LatestMigration); !errors.Is(err, ndb.ErrMigrationChecksum) {
+			t.Fatalf("expected ErrMigrationChecksum, got: %v", err)
+		}
+	})
+
+	mustStep(t, "06_down", func(t *testing.T) {
+		if err := mb.Migrate(1); err != nil {
+			t.Fatalf("migrate_down_error: %v", err)
+		}
+		if versions := appliedVersions(t, mb); len(versions) != 1 || versions[0] != 1 {
+			t.Fatalf("history_after_down versions=%v", versions)
+		}
+		if s, ok := bridge.GetSchemaByName("mig_notes"); !ok || s.GetField("body") != nil {
+			t.Fatalf("stored_schema_after_down ok=%t", ok)
+		}
+
+		if err := mb.Migrate(0); err != nil {
+			t.Fatalf("migrate_zero_error: %v", err)
+		}
+		if _, ok := bridge.GetSchemaByName("mig_notes"); ok {
+			t.Fatalf("schema should be deleted")
+		}
+	})
+
+	mustStep(t, "07_errors", func(t *testing.T) {
+		failing := bridge.WithContext(context.Background())
+		failing.AddMigrations(&ndb.Migration{
+			Version: 10,
+			Name:    "broken",
+			Up: func(tx *ndb.DBBridge) error {
+				if _, err := tx.ExecuteQuery(`CREATE TABLE "ndb_mig_tmp" (id INT)`); err != nil {
+					return err
+				}
+				return errors.New("boom")
+			},
+		})
+		if err := failing.Migrate(ndb.LatestMigration); err == nil {
+			t.Fatalf("expected migration error")
+		}
+
+		rows, err := bridge.ExecuteQuery(`SELECT to_regclass('ndb_mig_tmp') IS NULL AS gone`)
+		if err != nil || rows[0]["gone"] != true {
+			t.Fatalf("failed migration not rolled back rows=%v err=%v", rows, err)
+		}
+		if versions := appliedVersions(t, failing); len(versions) != 0 {
+			t.Fatalf("failed migration recorded versions=%v", versions)
+		}
+
+		failing.AddMigrations(&ndb.Migration{Version: 10, Up: func(tx *ndb.DBBridge) error { return nil }})
+		if err := failing.Migrate(ndb.LatestMigration); !errors.Is(err, ndb.ErrInvalidMigration) {
+			t.Fatalf("expected ErrInvalidMigration, got: %v", err)
+		}
+
+		irreversible := bridge.WithContext(context.Background())
+		irreversible.AddMigrations(&ndb.Migration{Version: 20, Name: "noop", Up: func(tx *ndb.DBBridge) error { return nil }})
+		if err := irreversible.Migrate(ndb.LatestMigration); err != nil {
+			t.Fatalf("migrate_error: %v", err)
+		}
+		if err := irreversible.Migrate(0); !errors.Is(err, ndb.ErrIrreversibleMigration) {
+			t.Fatalf("expected ErrIrreversibleMigration, got: %v", err)
+		}
+	})
+}
+
+// runs without a database: ndb_migrations is emulated on the dry-run responses
+func TestMigrateChecksumReplay(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	// version -> checksum, written by the migration INSERT and read back by Migrate
+	history := map[int64]string{}
+	dry.Respond(func(stmt ndb.DryRunStatement) []ndb.M {
+		switch {
+		case strings.HasPrefix(stmt.SQL, `INSERT INTO "`+ndb.MigrationsTable+`" (version`):
+			history[stmt.Args[0].(int64)] = stmt.Args[2].(string)
+		case strings.HasPrefix(stmt.SQL, `SELECT version, checksum`):
+			rows := []ndb.M{}
+			for version, sum := range history {
+				// dry-run columns come in key order
+				rows = append(rows, ndb.M{"1_version": version, "2_checksum": sum})
+			}
+			return rows
+		}
+		return nil
+	})
+
+	var ups int
+	var notes *ndb.Schema
+	migrations := func() []*ndb.Migration {
+		notes = ndb.NewSchema("replay_notes").NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField()
+		return []*ndb.Migration{
+			{Version: 1, Name: "create_notes", Up: func(tx *ndb.DBBridge) error { return tx.CreateSchema(notes) }},
+			{Version: 2, Name: "count", Up: func(tx *ndb.DBBridge) error { ups++; return nil }},
+		}
+	}
+	newBridge := func(t *testing.T, migrations ...*ndb.Migration) *ndb.DBBridge {
+		b := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+		b.AddMigrations(migrations...)
+		return b
+	}
+
+	mustStep(t, "01_fresh_database_replays_nothing", func(t *testing.T) {
+		if err := newBridge(t, migrations()...).Migrate(ndb.LatestMigration); err != nil {
+			t.Fatalf("migrate_error: %v", err)
+		}
+		if ups != 1 || len(history) != 2 {
+			t.Fatalf("fresh_run_mismatch ups=%d history=%v", ups, history)
+		}
+	})
+
+	mustStep(t, "02_applied_replayed_on_copies", func(t *testing.T) {
+		if err := newBridge(t, migrations()...).Migrate(ndb.LatestMigration); err != nil {
+			t.Fatalf("migrate_error: %v", err)
+		}
+		if ups != 2 {
+			t.Fatalf("replay_count_mismatch ups=%d", ups)
+		}
+		if notes.Metadata != nil {
+			t.Fatalf("replay stamped storage metadata on the migration schema")
+		}
+	})
+
+	mustStep(t, "03_edited_migration_fails", func(t *testing.T) {
+		edited := migrations()
+		edited[0].Up = func(tx *ndb.DBBridge) error {
+			return tx.CreateSchema(ndb.NewSchema("replay_notes").NewField("id").Type(ndb.FIELD_BIG_INT).PK().DoneField())
+		}
+		if err := newBridge(t, edited...).Migrate(ndb.LatestMigration); !errors.Is(err, ndb.ErrMigrationChecksum) {
+			t.Fatalf("expected ErrMigrationChecksum, got: %v", err)
+		}
+	})
+
+	mustStep(t, "04_replay_failure_reported", func(t *testing.T) {
+		broken := migrations()
+		broken[1].Up = func(tx *ndb.DBBridge) error {
+			body := &ndb.SchemaField{PName: "body", PType: ndb.FIELD_TEXT, PNullable: true}
+			return tx.ModifySchema("replay_missing", []*ndb.AlterField{{Field: body, AlterAction: ndb.ADD_COLUMN}})
+		}
+		if err := newBridge(t, broken...).Migrate(ndb.LatestMigration); err == nil || !strings.Contains(err.Error(), "checksum replay") {
+			t.Fatalf("expected replay error, got: %v", err)
+		}
+	})
+}
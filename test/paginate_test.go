@@ -0,0 +1,74 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestPaginate(t *testing.T) {
+	newQuery := func() *ndb.Query {
+		return ndb.NewReadQuery(usersTable.PName).
+			Fields("id", "email").
+			Where(ndb.M{"status": "active"}).
+			Order(ndb.Fs("users.id", "ASC"))
+	}
+
+	mustStep(t, "01_reset_and_seed", func(t *testing.T) {
+		resetSchemas(t)
+
+		payloads := make([]ndb.M, 0, 23)
+		for i := range 23 {
+			payloads = append(payloads, ndb.M{"public_id": uuid.NewString(), "email": "page_" + strconv.Itoa(i) + "@test.com"})
+		}
+		payloads = append(payloads, ndb.M{"public_id": uuid.NewString(), "email": "blocked@test.com", "status": "blocked"})
+
+		if _, err := bridge.CreateMany(ndb.NewCreateQuery(usersTable.PName).Payloads(payloads)); err != nil {
+			t.Fatalf("seed_error: %v", err)
+		}
+	})
+
+	mustStep(t, "02_exact_count", func(t *testing.T) {
+		page, err := bridge.Paginate(newQuery(), 3, 10)
+		if err != nil {
+			t.Fatalf("paginate_error: %v", err)
+		}
+		if page.Total != 23 || page.Pages != 3 || len(page.Items) != 3 || page.Items[0]["id"].(int64) != 21 {
+			t.Fatalf("exact_page_mismatch: total=%d pages=%d items=%v", page.Total, page.Pages, page.Items)
+		}
+	})
+
+	mustStep(t, "03_window_count", func(t *testing.T) {
+		page, err := bridge.PaginateWith(newQuery(), 1, 10, ndb.COUNT_WINDOW)
+		if err != nil {
+			t.Fatalf("paginate_window_error: %v", err)
+		}
+		if page.Total != 23 || page.Pages != 3 || len(page.Items) != 10 {
+			t.Fatalf("window_page_mismatch: total=%d pages=%d items=%d", page.Total, page.Pages, len(page.Items))
+		}
+		if _, ok := page.Items[0]["ndb_total"]; ok {
+			t.Fatalf("window_total_leaked_into_items")
+		}
+
+		past, err := bridge.PaginateWith(newQuery(), 9, 10, ndb.COUNT_WINDOW)
+		if err != nil || past.Total != 23 || len(past.Items) != 0 {
+			t.Fatalf("window_past_last_page_mismatch err=%v page=%+v", err, past)
+		}
+	})
+
+	mustStep(t, "04_estimate_count", func(t *testing.T) {
+		if _, err := bridge.ExecuteQuery(`ANALYZE "ndb_users"`); err != nil {
+			t.Fatalf("analyze_error: %v", err)
+		}
+
+		page, err := bridge.PaginateWith(newQuery(), 1, 10, ndb.COUNT_ESTIMATE)
+		if err != nil {
+			t.Fatalf("paginate_estimate_error: %v", err)
+		}
+		if page.Total != 24 {
+			t.Fatalf("estimate_mismatch expected=24 actual=%d", page.Total)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestPostgresErrors(t *testing.T) {
+	var userID any
+
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "dup@test.com"}).
+			Fields("id")
+		row, err := bridge.CreateOne(q)
+		if err != nil {
+			t.Fatalf("create_user_error: %v", err)
+		}
+		userID = row["id"]
+	})
+
+	mustStep(t, "02_unique_violation", func(t *testing.T) {
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "dup@test.com"}).
+			Fields("id")
+		_, err := bridge.CreateOne(q)
+		if !errors.Is(err, ndb.ErrUniqueViolation) {
+			t.Fatalf("expected unique violation, got: %v", err)
+		}
+
+		var dbErr *ndb.DBError
+		if !errors.As(err, &dbErr) {
+			t.Fatalf("expected *ndb.DBError, got: %T", err)
+		}
+		if dbErr.Table != usersTable.PName || len(dbErr.Columns) != 1 || dbErr.Columns[0] != "email" {
+			t.Fatalf("unique_violation_mismatch table=%q columns=%v", dbErr.Table, dbErr.Columns)
+		}
+		if len(dbErr.Fields) != 1 || dbErr.Fields[0].PName != "email" {
+			t.Fatalf("unique_violation_fields_mismatch fields=%v", dbErr.Fields)
+		}
+		if dbErr.HTTPStatus() != http.StatusConflict {
+			t.Fatalf("unique_violation_status_mismatch status=%d", dbErr.HTTPStatus())
+		}
+
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) {
+			t.Fatalf("expected wrapped *pq.Error")
+		}
+	})
+
+	mustStep(t, "03_foreign_key_violation", func(t *testing.T) {
+		q := ndb.NewCreateQuery(userType.PName).Payload(ndb.M{"user_id": 999999})
+		_, err := bridge.Create(q)
+		if !errors.Is(err, ndb.ErrForeignKeyViolation) {
+			t.Fatalf("expected foreign key violation, got: %v", err)
+		}
+
+		var dbErr *ndb.DBError
+		if !errors.As(err, &dbErr) || dbErr.Table != userType.PName || len(dbErr.Columns) != 1 || dbErr.Columns[0] != "user_id" {
+			t.Fatalf("foreign_key_violation_mismatch err=%+v", dbErr)
+		}
+		if dbErr.HTTPStatus() != http.StatusUnprocessableEntity {
+			t.Fatalf("foreign_key_violation_status_mismatch status=%d", dbErr.HTTPStatus())
+		}
+	})
+
+	mustStep(t, "04_not_null_violation_raw_query", func(t *testing.T) {
+		_, err := bridge.ExecuteQuery(`INSERT INTO "ndb_users_type" (user_id) VALUES (NULL)`)
+		if !errors.Is(err, ndb.ErrNotNullViolation) {
+			t.Fatalf("expected not null violation, got: %v", err)
+		}
+
+		var dbErr *ndb.DBError
+		if !errors.As(err, &dbErr) || len(dbErr.Columns) != 1 || dbErr.Columns[0] != "user_id" {
+			t.Fatalf("not_null_violation_mismatch err=%+v", dbErr)
+		}
+	})
+
+	mustStep(t, "05_check_violation_in_transaction", func(t *testing.T) {
+		err := bridge.Transaction(func(tx *ndb.DBBridge) error {
+			_, err := tx.ExecuteQuery(`INSERT INTO "ndb_user_payments" (user_id, arr_field) VALUES ($1, '{5}')`, userID)
+			return err
+		})
+		if !errors.Is(err, ndb.ErrCheckViolation) {
+			t.Fatalf("expected check violation, got: %v", err)
+		}
+
+		var dbErr *ndb.DBError
+		if !errors.As(err, &dbErr) || len(dbErr.Fields) != 1 || dbErr.Fields[0].PName != "arr_field" {
+			t.Fatalf("check_violation_mismatch err=%+v", dbErr)
+		}
+	})
+}
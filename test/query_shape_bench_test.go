@@ -0,0 +1,76 @@
+package test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+func benchShapeQuery(id int) *ndb.Query {
+	return ndb.NewReadQuery(clientsArrTable.GetName()).
+		Fields("id", "name", "grant_types", "redirect_uris", "created_at").
+		Where(ndb.M{"id": ndb.M{"gte": id, "lt": id + 64}, "name": ndb.M{"ilike": "seed_%"}}).
+		Order(ndb.Fs("clients_arr_test.id", "ASC")).
+		Limit(64)
+}
+
+func benchBuildReadQuery(b *testing.B, br *ndb.DBBridge) {
+	q := benchShapeQuery(0)
+	rng := q.PWhere[0]["id"].(ndb.M)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rng["gte"], rng["lt"] = i, i+64
+
+		query, args, err := br.BuildReadQuery(q)
+		if err != nil {
+			b.Fatalf("build_error: %v", err)
+		}
+		if query == "" || len(args) != 3 {
+			b.Fatalf("invalid_build args=%d", len(args))
+		}
+	}
+}
+
+func BenchmarkBuildReadQuery_NoShapeCache(b *testing.B) {
+	br := ndb.NewBridge(&ndb.NBridge{SchemaPrefix: "ndb_", SchemaStorage: schemaStorage})
+	benchBuildReadQuery(b, br)
+}
+
+func BenchmarkBuildReadQuery_ShapeCache(b *testing.B) {
+	br := ndb.NewBridge(&ndb.NBridge{SchemaPrefix: "ndb_", SchemaStorage: schemaStorage, QueryShapeCacheSize: 128})
+	benchBuildReadQuery(b, br)
+
+	if stats := br.QueryShapeCacheStats(); stats.Misses != 1 {
+		b.Fatalf("shape_cache_misses_mismatch expected=1 actual=%d", stats.Misses)
+	}
+}
+
+func BenchmarkReadB_ClientArr_ShapeCache(b *testing.B) {
+	ids, cleanup := benchResetClientsArr(b)
+	defer cleanup()
+
+	db, err := sql.Open("postgres", testDSN)
+	if err != nil {
+		b.Fatalf("open_error: %v", err)
+	}
+	defer db.Close()
+
+	br := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: schemaStorage, QueryShapeCacheSize: 128})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var rows []ClientArr
+		if err := br.ReadB(benchShapeQuery(int(ids[i%len(ids)])), &rows); err != nil {
+			b.Fatalf("read_error: %v", err)
+		}
+		if len(rows) == 0 {
+			b.Fatalf("empty_rows")
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestReadIter(t *testing.T) {
+	const total = 250
+
+	mustStep(t, "01_reset_and_seed", func(t *testing.T) {
+		resetSchemas(t)
+
+		payloads := make([]ndb.M, 0, total)
+		for i := range total {
+			payloads = append(payloads, ndb.M{"public_id": uuid.NewString(), "email": "iter_" + strconv.Itoa(i) + "@test.com"})
+		}
+
+		if _, err := bridge.CreateMany(ndb.NewCreateQuery(usersTable.PName).Payloads(payloads).Fields("id")); err != nil {
+			t.Fatalf("seed_error: %v", err)
+		}
+	})
+
+	mustStep(t, "02_default_limit_still_applies", func(t *testing.T) {
+		rows, err := bridge.Read(ndb.NewReadQuery(usersTable.PName).Fields("id"))
+		if err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+		if len(rows) != 100 {
+			t.Fatalf("default_limit_mismatch expected=100 actual=%d", len(rows))
+		}
+	})
+
+	mustStep(t, "03_unbounded_iteration", func(t *testing.T) {
+		q := ndb.NewReadQuery(usersTable.PName).
+			Fields("id", "email").
+			Order(ndb.Fs("users.id", "ASC")).
+			Unbounded()
+
+		var count int
+		var lastID int64
+		for row, err := range bridge.ReadIter(q) {
+			if err != nil {
+				t.Fatalf("iter_error: %v", err)
+			}
+			id := row["id"].(int64)
+			if id <= lastID {
+				t.Fatalf("iter_order_mismatch last=%d id=%d", lastID, id)
+			}
+			lastID = id
+			count++
+		}
+
+		if count != total {
+			t.Fatalf("iter_count_mismatch expected=%d actual=%d", total, count)
+		}
+	})
+
+	mustStep(t, "04_early_break", func(t *testing.T) {
+		var count int
+		for _, err := range bridge.ReadIter(ndb.NewReadQuery(usersTable.PName).Unbounded()) {
+			if err != nil {
+				t.Fatalf("iter_error: %v", err)
+			}
+			count++
+			if count == 10 {
+				break
+			}
+		}
+
+		if count != 10 {
+			t.Fatalf("early_break_mismatch actual=%d", count)
+		}
+	})
+
+	mustStep(t, "05_build_error_is_yielded", func(t *testing.T) {
+		for _, err := range bridge.ReadIter(ndb.NewDeleteQuery(usersTable.PName)) {
+			if err != ndb.ErrInvalidQueryType {
+				t.Fatalf("expected ErrInvalidQueryType, got: %v", err)
+			}
+		}
+	})
+}
+
+// runs without a database: a JSON limit can never drop the LIMIT clause
+func TestNegativeLimitRejected(t *testing.T) {
+	db, _ := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+	if err := preview.CreateSchema(detached(usersTable)); err != nil {
+		t.Fatalf("create_schema_error: %v", err)
+	}
+
+	q := ndb.NewReadQuery(usersTable.PName)
+	if err := json.Unmarshal([]byte(`{"limit": -1}`), q); err != nil {
+		t.Fatalf("unmarshal_error: %v", err)
+	}
+	if q.IsUnbounded() {
+		t.Fatalf("negative JSON limit made the query unbounded")
+	}
+	if _, _, err := preview.BuildReadQuery(q); !errors.Is(err, ndb.ErrNegativeLimit) {
+		t.Fatalf("expected ErrNegativeLimit, got: %v", err)
+	}
+
+	sql, _, err := preview.BuildReadQuery(ndb.NewReadQuery(usersTable.PName).Fields("id").Unbounded())
+	if err != nil || strings.Contains(sql, "LIMIT") {
+		t.Fatalf("unbounded_sql_mismatch sql=%q err=%v", sql, err)
+	}
+}
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	primary, _ := sql.Open("postgres", testDSN)
+	replica, _ := sql.Open("postgres", testDSN)
+	defer primary.Close()
+	defer replica.Close()
+
+	// without idle connections every statement closes its connection, so
+	// MaxIdleClosed counts the statements each pool ran
+	primary.SetMaxIdleConns(0)
+	replica.SetMaxIdleConns(0)
+	used := func(db *sql.DB) int64 { return db.Stats().MaxIdleClosed }
+
+	routed := ndb.NewBridge(&ndb.NBridge{
+		DB:                   primary,
+		Replicas:             []*sql.DB{replica},
+		ReadYourWritesWindow: 200 * time.Millisecond,
+		SchemaPrefix:         bridge.GetSchemaPrefix(),
+		SchemaStorage:        schemaStorage,
+	})
+
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_reads_go_to_replica", func(t *testing.T) {
+		p, r := used(primary), used(replica)
+		if _, err := routed.Read(ndb.NewReadQuery(usersTable.PName)); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+		if used(replica) != r+1 || used(primary) != p {
+			t.Fatalf("read_not_routed_to_replica")
+		}
+	})
+
+	mustStep(t, "03_writes_go_to_primary_and_open_window", func(t *testing.T) {
+		p, r := used(primary), used(replica)
+		q := ndb.NewCreateQuery(usersTable.PName).Payload(ndb.M{"public_id": uuid.NewString(), "email": "replica@test.com"})
+		if _, err := routed.Create(q); err != nil {
+			t.Fatalf("create_error: %v", err)
+		}
+
+		if _, err := routed.ReadOne(ndb.NewReadQuery(usersTable.PName).Where(ndb.M{"email": "replica@test.com"})); err != nil {
+			t.Fatalf("read_your_writes_error: %v", err)
+		}
+		if used(primary) != p+2 || used(replica) != r {
+			t.Fatalf("read_inside_window_not_on_primary")
+		}
+	})
+
+	mustStep(t, "04_primary_escape_hatch", func(t *testing.T) {
+		time.Sleep(250 * time.Millisecond)
+
+		p, r := used(primary), used(replica)
+		if _, err := routed.Read(ndb.NewReadQuery(usersTable.PName).Primary()); err != nil {
+			t.Fatalf("primary_read_error: %v", err)
+		}
+		if used(primary) != p+1 || used(replica) != r {
+			t.Fatalf("primary_read_not_on_primary")
+		}
+	})
+
+	mustStep(t, "05_transactions_stay_on_primary", func(t *testing.T) {
+		r := used(replica)
+		err := routed.Transaction(func(tx *ndb.DBBridge) error {
+			_, err := tx.Read(ndb.NewReadQuery(usersTable.PName))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("transaction_error: %v", err)
+		}
+		if used(replica) != r {
+			t.Fatalf("transaction_read_went_to_replica")
+		}
+	})
+
+	mustStep(t, "06_primary_reads_keep_replicas", func(t *testing.T) {
+		time.Sleep(250 * time.Millisecond)
+
+		if _, err := routed.ExecuteQuery(`SELECT 1`); err != nil {
+			t.Fatalf("raw_select_error: %v", err)
+		}
+		if _, err := routed.Read(ndb.NewReadQuery(usersTable.PName).Primary()); err != nil {
+			t.Fatalf("primary_read_error: %v", err)
+		}
+
+		r := used(replica)
+		if _, err := routed.Read(ndb.NewReadQuery(usersTable.PName)); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+		if used(replica) != r+1 {
+			t.Fatalf("read_after_primary_reads_not_on_replica")
+		}
+	})
+
+	mustStep(t, "07_transaction_write_opens_window_on_commit", func(t *testing.T) {
+		err := routed.Transaction(func(tx *ndb.DBBridge) error {
+			_, err := tx.ExecuteQuery(`UPDATE "ndb_users" SET username = 'replica' WHERE email = 'replica@test.com'`)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("transaction_error: %v", err)
+		}
+
+		r := used(replica)
+		if _, err := routed.Read(ndb.NewReadQuery(usersTable.PName)); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+		if used(replica) != r {
+			t.Fatalf("read_after_committed_write_went_to_replica")
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+// usersTable with email narrowed, nickname added, updated_at dropped and status indexed
+func desiredUsers() *ndb.Schema {
+	return ndb.NewSchema("users").
+		Comment("User Table").
+		UniqueIndex("public_id").
+		Indexes("email", "username").
+		Indexes("status").
+		NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField().
+		NewField("public_id").Type(ndb.FIELD_UUID).Unique().DoneField().
+		NewField("email").Type(ndb.FIELD_VARCHAR).Max(100).Unique().DoneField().
+		NewField("username").Type(ndb.FIELD_VARCHAR).Max(100).Unique().Nullable().DoneField().
+		NewField("status").Type(ndb.FIELD_VARCHAR).Max(20).Default("'active'").DoneField().
+		NewField("nickname").Type(ndb.FIELD_VARCHAR).Max(50).Nullable().DoneField().
+		NewField("created_at").Type(ndb.FIELD_TIMESTAMP).Default("now()").DoneField()
+}
+
+func stepDescriptions(diff *ndb.SchemaDiff) []string {
+	out := make([]string, len(diff.Steps))
+	for i, s := range diff.Steps {
+		out[i] = s.Description
+	}
+	return out
+}
+
+func TestSchemaDiff(t *testing.T) {
+	desired := desiredUsers()
+	var diff *ndb.SchemaDiff
+
+	mustStep(t, "01_no_changes_after_create", func(t *testing.T) {
+		resetSchemas(t)
+
+		for _, s := range []*ndb.Schema{usersTable, userType, userPayments} {
+			d, err := bridge.Diff(s)
+			if err != nil {
+				t.Fatalf("diff_error schema=%s: %v", s.PName, err)
+			}
+			if !d.Empty() || len(d.Drift) != 0 {
+				t.Fatalf("unexpected_changes schema=%s steps=%v drift=%v", s.PName, stepDescriptions(d), d.Drift)
+			}
+		}
+	})
+
+	mustStep(t, "02_plan_changes", func(t *testing.T) {
+		var err error
+		if diff, err = bridge.Diff(desired); err != nil {
+			t.Fatalf("diff_error: %v", err)
+		}
+
+		steps := stepDescriptions(diff)
+		for _, prefix := range []string{"alter column email", "add column nickname", "drop column updated_at", "add index (status)"} {
+			if !slices.ContainsFunc(steps, func(s string) bool { return strings.HasPrefix(s, prefix) }) {
+				t.Fatalf("missing_step prefix=%q steps=%v", prefix, steps)
+			}
+		}
+		if len(diff.Fields) != 3 || !diff.Destructive() {
+			t.Fatalf("fields_mismatch fields=%d destructive=%t", len(diff.Fields), diff.Destructive())
+		}
+
+		plan := bridge.Plan(diff)
+		if !strings.Contains(plan, "-- DESTRUCTIVE: drop column updated_at") || !strings.Contains(plan, "TYPE VARCHAR(100)") {
+			t.Fatalf("plan_mismatch plan=%s", plan)
+		}
+	})
+
+	mustStep(t, "03_apply", func(t *testing.T) {
+		if err := bridge.Apply(diff, false); !errors.Is(err, ndb.ErrDestructivePlan) {
+			t.Fatalf("expected ErrDestructivePlan, got: %v", err)
+		}
+		replaced, _ := bridge.GetSchemaByName(desired.PName)
+		if err := bridge.Apply(diff, true); err != nil {
+			t.Fatalf("apply_error: %v", err)
+		}
+		if desired.Metadata == replaced.Metadata {
+			t.Fatalf("applied schema shares the metadata of the replaced one")
+		}
+
+		again, err := bridge.Diff(desired)
+		if err != nil {
+			t.Fatalf("diff_error: %v", err)
+		}
+		if !again.Empty() || len(again.Drift) != 0 {
+			t.Fatalf("changes_after_apply steps=%v drift=%v", stepDescriptions(again), again.Drift)
+		}
+	})
+
+	mustStep(t, "04_drift_and_create", func(t *testing.T) {
+		if _, err := bridge.ExecuteQuery(`ALTER TABLE "ndb_users" ADD COLUMN extra TEXT`); err != nil {
+			t.Fatalf("alter_error: %v", err)
+		}
+
+		d, err := bridge.Diff(desired)
+		if err != nil {
+			t.Fatalf("diff_error: %v", err)
+		}
+		if len(d.Drift) == 0 || !slices.Contains(stepDescriptions(d), "drop column extra") {
+			t.Fatalf("drift_mismatch steps=%v drift=%v", stepDescriptions(d), d.Drift)
+		}
+
+		d, err = bridge.Diff(ndb.NewSchema("diff_missing").NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField())
+		if err != nil {
+			t.Fatalf("diff_error: %v", err)
+		}
+		if !d.Create || len(d.Steps) != 1 {
+			t.Fatalf("create_mismatch diff=%+v", d)
+		}
+	})
+
+	mustStep(t, "05_not_null_is_destructive", func(t *testing.T) {
+		required := desiredUsers()
+		required.GetField("nickname").PNullable = false
+
+		d, err := bridge.Diff(required)
+		if err != nil {
+			t.Fatalf("diff_error: %v", err)
+		}
+		i := slices.IndexFunc(d.Steps, func(s *ndb.PlanStep) bool { return strings.HasPrefix(s.Description, "alter column nickname") })
+		if i < 0 || !d.Steps[i].Destructive {
+			t.Fatalf("not_null_step_mismatch steps=%v", stepDescriptions(d))
+		}
+	})
+
+	mustStep(t, "06_restore", func(t *testing.T) {
+		resetSchemas(t)
+	})
+}
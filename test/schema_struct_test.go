@@ -0,0 +1,112 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+type TaggedOrder struct {
+	ID        int64           `json:"id" ndb:"pk,type=BIGSERIAL"`
+	PublicID  uuid.UUID       `json:"public_id" ndb:"unique,default=gen_random_uuid()"`
+	UserID    int64           `json:"user_id" ndb:"fk=users.id,on_delete=CASCADE,index"`
+	Status    string          `json:"status" ndb:"max=20,default='new',enum='new'|'paid',index=status_created"`
+	Tags      []string        `json:"tags" ndb:"nullable"`
+	Scores    []int64         `json:"scores" ndb:"nullable"`
+	Payload   json.RawMessage `json:"payload"`
+	Total     float64         `json:"total" ndb:"min=0"`
+	CreatedAt time.Time       `json:"created_at" ndb:"default=now(),index=status_created"`
+	PaidAt    *time.Time      `json:"paid_at"`
+	Internal  string          `json:"-" ndb:"-"`
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	var schema *ndb.Schema
+
+	mustStep(t, "01_infer_fields", func(t *testing.T) {
+		var err error
+		schema, err = ndb.SchemaFromStruct[TaggedOrder]("tagged_orders")
+		if err != nil {
+			t.Fatalf("schema_from_struct_error: %v", err)
+		}
+
+		expected := map[string]ndb.SchemaFieldType{
+			"id":         ndb.FIELD_BIG_SERIAL,
+			"public_id":  ndb.FIELD_UUID,
+			"user_id":    ndb.FIELD_BIG_INT,
+			"status":     ndb.FIELD_VARCHAR,
+			"tags":       ndb.FIELD_TEXT_ARRAY,
+			"scores":     ndb.FIELD_BIG_INT_ARRAY,
+			"payload":    ndb.FIELD_JSONB,
+			"total":      ndb.FIELD_DOUBLE,
+			"created_at": ndb.FIELD_TIMESTAMP,
+			"paid_at":    ndb.FIELD_TIMESTAMP,
+		}
+		if len(schema.PFields) != len(expected) {
+			t.Fatalf("fields_len_mismatch fields=%d", len(schema.PFields))
+		}
+		for name, typ := range expected {
+			if f := schema.GetField(name); f == nil || f.PType != typ {
+				t.Fatalf("field_type_mismatch field=%s expected=%s got=%+v", name, typ, f)
+			}
+		}
+
+		if fk := schema.GetField("user_id").PForeignKey; fk == nil || fk.PSchema != "users" || fk.POnDelete != ndb.CASCADE {
+			t.Fatalf("fk_mismatch fk=%+v", fk)
+		}
+		if f := schema.GetField("status"); *f.PMax != 20 || *f.PDefault != "'new'" || len(f.PEnumValues) != 2 {
+			t.Fatalf("status_field_mismatch field=%+v", f)
+		}
+		if !schema.GetField("paid_at").PNullable || schema.GetField("total").PNullable {
+			t.Fatalf("nullable_mismatch")
+		}
+		if len(schema.PIndexes) != 2 || strings.Join(schema.PIndexes[1], ",") != "status,created_at" {
+			t.Fatalf("indexes_mismatch indexes=%v", schema.PIndexes)
+		}
+	})
+
+	mustStep(t, "02_create_schema_ddl", func(t *testing.T) {
+		db, dry := ndb.NewDryRunDB()
+		defer db.Close()
+
+		bridge := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+
+		if err := bridge.CreateSchema(schema); err != nil {
+			t.Fatalf("create_schema_error: %v", err)
+		}
+
+		// schema DDL runs right after BEGIN, before its history row
+		stmts := dry.Statements()
+		ddl := stmts[1].SQL
+		for _, part := range []string{
+			"status VARCHAR(20) NOT NULL DEFAULT 'new'",
+			`user_id BIGINT NOT NULL REFERENCES "ndb_users"(id) ON DELETE CASCADE`,
+			"public_id UUID UNIQUE NOT NULL DEFAULT gen_random_uuid()",
+			"CREATE INDEX idx_tagged_orders_status_created_at",
+		} {
+			if !strings.Contains(ddl, part) {
+				t.Fatalf("ddl_missing part=%q ddl=%s", part, ddl)
+			}
+		}
+	})
+
+	mustStep(t, "03_invalid_tags", func(t *testing.T) {
+		type badType struct {
+			Name string `ndb:"type=STRING"`
+		}
+		if _, err := ndb.SchemaFromStruct[badType]("bad"); err == nil {
+			t.Fatalf("expected unknown type error")
+		}
+
+		type badRule struct {
+			OwnerID int64 `ndb:"on_delete=CASCADE"`
+		}
+		if _, err := ndb.SchemaFromStruct[badRule]("bad"); err == nil {
+			t.Fatalf("expected missing fk error")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+	"github.com/nitsugaro/go-nstore"
+)
+
+// runs without a database: rolled back schema operations must leave the storage as it was
+func TestSchemaTransactions(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	folder := filepath.Join(t.TempDir(), "schemas")
+	storage, err := nstore.New[*ndb.Schema](folder)
+	if err != nil {
+		t.Fatalf("storage_error: %v", err)
+	}
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: storage})
+	errAbort := errors.New("abort")
+
+	notes := func() *ndb.Schema {
+		return ndb.NewSchema("tx_notes").
+			NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField().
+			NewField("title").Type(ndb.FIELD_TEXT).DoneField()
+	}
+	storedFiles := func(t *testing.T) int {
+		t.Helper()
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			t.Fatalf("read_dir_error: %v", err)
+		}
+		return len(entries)
+	}
+
+	mustStep(t, "01_create_rolled_back", func(t *testing.T) {
+		err := preview.Transaction(func(tx *ndb.DBBridge) error {
+			if err := tx.CreateSchema(notes()); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("expected abort, got: %v", err)
+		}
+
+		if _, ok := preview.GetSchemaByName("tx_notes"); ok || storedFiles(t) != 0 {
+			t.Fatalf("rolled back schema still stored files=%d", storedFiles(t))
+		}
+
+		stmts := dry.Statements()
+		// the history row is written in the savepoint and rolled back with it
+		if len(stmts) != 8 || !strings.HasPrefix(stmts[1].SQL, "SAVEPOINT") || !strings.HasPrefix(stmts[5].SQL, "INSERT INTO") || stmts[7].SQL != "ROLLBACK" {
+			t.Fatalf("statements_mismatch stmts=%+v", stmts)
+		}
+	})
+
+	mustStep(t, "02_create", func(t *testing.T) {
+		dry.Reset()
+		if err := preview.CreateSchema(notes()); err != nil {
+			t.Fatalf("create_schema_error: %v", err)
+		}
+
+		stmts := dry.Statements()
+		if len(stmts) != 6 || stmts[0].SQL != "BEGIN" || !strings.HasPrefix(stmts[4].SQL, "INSERT INTO") || stmts[5].SQL != "COMMIT" {
+			t.Fatalf("statements_mismatch stmts=%+v", stmts)
+		}
+		if _, ok := preview.GetSchemaByName("tx_notes"); !ok || storedFiles(t) != 1 {
+			t.Fatalf("schema not stored files=%d", storedFiles(t))
+		}
+	})
+
+	mustStep(t, "03_modify_and_delete_rolled_back", func(t *testing.T) {
+		body := &ndb.SchemaField{PName: "body", PType: ndb.FIELD_TEXT, PNullable: true}
+
+		err := preview.Transaction(func(tx *ndb.DBBridge) error {
+			if err := tx.ModifySchema("tx_notes", []*ndb.AlterField{{Field: body, AlterAction: ndb.ADD_COLUMN}}); err != nil {
+				return err
+			}
+			if err := tx.DeleteSchema("tx_notes"); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("expected abort, got: %v", err)
+		}
+
+		stored, ok := preview.GetSchemaByName("tx_notes")
+		if !ok || stored.GetField("body") != nil || len(stored.PFields) != 2 {
+			t.Fatalf("stored_schema_not_restored ok=%t", ok)
+		}
+
+		reloaded, _ := nstore.New[*ndb.Schema](folder)
+		if err := reloaded.LoadFromDisk(); err != nil || len(reloaded.ListOfCache()) != 1 || reloaded.ListOfCache()[0].GetField("body") != nil {
+			t.Fatalf("stored_file_not_restored err=%v", err)
+		}
+	})
+
+	mustStep(t, "04_storage_failure_rolls_back", func(t *testing.T) {
+		if err := os.RemoveAll(folder); err != nil {
+			t.Fatalf("remove_error: %v", err)
+		}
+		dry.Reset()
+
+		if err := preview.CreateSchema(ndb.NewSchema("tx_other").NewField("id").Type(ndb.FIELD_BIG_SERIAL).PK().DoneField()); err == nil {
+			t.Fatalf("expected storage error")
+		}
+		if _, ok := preview.GetSchemaByName("tx_other"); ok {
+			t.Fatalf("failed write left the schema in the storage cache")
+		}
+		if last := dry.Last().SQL; last != "ROLLBACK" {
+			t.Fatalf("expected ROLLBACK, got: %q", last)
+		}
+	})
+}
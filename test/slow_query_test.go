@@ -0,0 +1,78 @@
+package test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestSlowQueryLogAndExplain(t *testing.T) {
+	db, _ := sql.Open("postgres", testDSN)
+	defer db.Close()
+
+	logged := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: bridge.GetSchemaPrefix(), SchemaStorage: schemaStorage})
+
+	var out bytes.Buffer
+	logged.AfterExecute(logged.SlowQueryLog(ndb.NewJSONSlowQuerySink(&out)))
+
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_fast_queries_are_not_logged", func(t *testing.T) {
+		if _, err := logged.Read(ndb.NewReadQuery(usersTable.PName)); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+		if out.Len() != 0 {
+			t.Fatalf("fast_query_logged: %s", out.String())
+		}
+	})
+
+	mustStep(t, "03_slow_query_is_logged_with_redacted_args", func(t *testing.T) {
+		// default threshold is 500ms
+		if _, err := logged.ExecuteQuery("SELECT pg_sleep($1), $2::text AS secret", 0.6, "s3cr3t"); err != nil {
+			t.Fatalf("slow_query_error: %v", err)
+		}
+
+		var record ndb.SlowQueryRecord
+		if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+			t.Fatalf("record_decode_error: %v (%s)", err, out.String())
+		}
+		if record.SQL == "" || record.Rows != 1 || len(record.Args) != 2 {
+			t.Fatalf("record_mismatch record=%+v", record)
+		}
+		if bytes.Contains(out.Bytes(), []byte("s3cr3t")) {
+			t.Fatalf("args_not_redacted: %s", out.String())
+		}
+		if record.Args[1] != "<string>" {
+			t.Fatalf("redacted_arg_mismatch args=%v", record.Args)
+		}
+	})
+
+	mustStep(t, "04_explain_returns_plan_tree", func(t *testing.T) {
+		q := ndb.NewReadQuery(usersTable.PName).
+			Where(ndb.M{"email": "explain@test.com"}).
+			Order(ndb.Fs("users.id", "ASC"))
+
+		plan, err := logged.Explain(q)
+		if err != nil {
+			t.Fatalf("explain_error: %v", err)
+		}
+		if plan.NodeType == "" || plan.TotalCost <= 0 {
+			t.Fatalf("plan_mismatch plan=%+v", plan)
+		}
+
+		var relations []string
+		plan.Walk(func(node *ndb.PlanNode, _ int) {
+			if node.RelationName != "" {
+				relations = append(relations, node.RelationName)
+			}
+		})
+		if len(relations) != 1 || relations[0] != bridge.GetSchemaPrefix()+usersTable.PName {
+			t.Fatalf("plan_relations_mismatch relations=%v", relations)
+		}
+	})
+}
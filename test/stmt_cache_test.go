@@ -0,0 +1,161 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestStatementCache(t *testing.T) {
+	db, _ := sql.Open("postgres", testDSN)
+	defer db.Close()
+
+	cached := ndb.NewBridge(&ndb.NBridge{
+		DB:                 db,
+		StatementCacheSize: 16,
+		SchemaPrefix:       bridge.GetSchemaPrefix(),
+		SchemaStorage:      schemaStorage,
+	})
+
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_same_shape_hits_cache", func(t *testing.T) {
+		for _, email := range []string{"a@test.com", "b@test.com", "c@test.com"} {
+			q := ndb.NewReadQuery(usersTable.PName).Fields("id").Where(ndb.M{"email": email})
+			if _, err := cached.Read(q); err != nil {
+				t.Fatalf("read_error: %v", err)
+			}
+		}
+
+		stats := cached.StatementCacheStats()
+		if stats.Misses != 1 || stats.Hits != 2 || stats.Size != 1 {
+			t.Fatalf("stats_mismatch: %+v", stats)
+		}
+	})
+
+	mustStep(t, "03_transaction_uses_tx_statements", func(t *testing.T) {
+		err := cached.Transaction(func(tx *ndb.DBBridge) error {
+			q := ndb.NewCreateQuery(usersTable.PName).Payload(ndb.M{"public_id": uuid.NewString(), "email": "stmt@test.com"})
+			if _, err := tx.Create(q); err != nil {
+				return err
+			}
+
+			rows, err := tx.Read(ndb.NewReadQuery(usersTable.PName).Fields("id").Where(ndb.M{"email": "stmt@test.com"}))
+			if err == nil && len(rows) != 1 {
+				return fmt.Errorf("tx_read_len_mismatch: %d", len(rows))
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("transaction_error: %v", err)
+		}
+
+		if stats := cached.StatementCacheStats(); stats.Hits != 3 {
+			t.Fatalf("tx_stats_mismatch: %+v", stats)
+		}
+	})
+
+	mustStep(t, "04_schema_changes_invalidate", func(t *testing.T) {
+		if err := cached.DeleteSchema(userPayments.PName); err != nil {
+			t.Fatalf("delete_schema_error: %v", err)
+		}
+
+		if stats := cached.StatementCacheStats(); stats.Size != 0 {
+			t.Fatalf("cache_not_invalidated: %+v", stats)
+		}
+	})
+
+	mustStep(t, "05_stale_plan_evicted_and_retried", func(t *testing.T) {
+		q := func() *ndb.Query {
+			return ndb.NewReadQuery(usersTable.PName).Fields("username").Where(ndb.M{"email": "stmt@test.com"})
+		}
+		if _, err := cached.Read(q()); err != nil {
+			t.Fatalf("read_error: %v", err)
+		}
+
+		// changing the result type outside the bridge leaves the cached plan stale
+		if _, err := db.Exec(`ALTER TABLE "ndb_users" ALTER COLUMN username TYPE TEXT`); err != nil {
+			t.Fatalf("alter_error: %v", err)
+		}
+		defer db.Exec(`ALTER TABLE "ndb_users" ALTER COLUMN username TYPE VARCHAR(100)`)
+
+		if _, err := cached.Read(q()); err != nil {
+			t.Fatalf("stale_read_error: %v", err)
+		}
+		if stats := cached.StatementCacheStats(); stats.Size != 0 {
+			t.Fatalf("stale_statement_not_evicted: %+v", stats)
+		}
+	})
+}
+
+// runs without a database: only failures known not to have run the statement are retried
+func TestStatementCacheRetries(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, StatementCacheSize: 4, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+	if err := preview.CreateSchema(detached(usersTable)); err != nil {
+		t.Fatalf("create_schema_error: %v", err)
+	}
+	defer dry.Fail(nil)
+
+	count := func(prefix string) (n int) {
+		for _, stmt := range dry.Statements() {
+			if strings.HasPrefix(stmt.SQL, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	mustStep(t, "01_client_error_not_resent", func(t *testing.T) {
+		dry.Reset()
+		lost := errors.New("connection reset by peer")
+		dry.Fail(func(stmt ndb.DryRunStatement) error {
+			if strings.HasPrefix(stmt.SQL, "INSERT") {
+				return lost
+			}
+			return nil
+		})
+
+		q := ndb.NewCreateQuery(usersTable.PName).Payload(ndb.M{"public_id": uuid.NewString(), "email": "lost@test.com"})
+		if _, err := preview.Create(q); !errors.Is(err, lost) {
+			t.Fatalf("expected the connection error, got: %v", err)
+		}
+		if n := count("INSERT"); n != 1 {
+			t.Fatalf("write re-sent inserts=%d", n)
+		}
+	})
+
+	mustStep(t, "02_stale_plan_evicted_and_retried", func(t *testing.T) {
+		dry.Reset()
+		stale := 0
+		dry.Fail(func(stmt ndb.DryRunStatement) error {
+			if strings.HasPrefix(stmt.SQL, "SELECT") {
+				if stale++; stale == 1 {
+					return &pq.Error{Code: "0A000", Message: "cached plan must not change result type"}
+				}
+			}
+			return nil
+		})
+
+		if _, err := preview.Read(ndb.NewReadQuery(usersTable.PName).Fields("id")); err != nil {
+			t.Fatalf("stale_read_error: %v", err)
+		}
+		if n := count("SELECT"); n != 2 {
+			t.Fatalf("stale read not retried selects=%d", n)
+		}
+		// only the INSERT of the previous step is left
+		if stats := preview.StatementCacheStats(); stats.Size != 1 {
+			t.Fatalf("stale statement still cached: %+v", stats)
+		}
+	})
+}
@@ -1,10 +1,14 @@
 package test
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/nitsugaro/go-ndb"
 )
 
@@ -103,7 +107,178 @@ func TestTransactionCommitAndRollback(t *testing.T) {
 		}
 	})
 
-	mustStep(t, "04_cleanup", func(t *testing.T) {
+	mustStep(t, "04_nested_savepoint_rolls_back_inner_only", func(t *testing.T) {
+		before := countTrxUsers(t)
+
+		err := bridge.Transaction(func(tx *ndb.DBBridge) error {
+			outer := ndb.NewCreateQuery(trxUsersTable.PName).
+				Payload(ndb.M{"email": "outer@test.com"}).
+				Fields("id")
+			if _, err := tx.CreateOne(outer); err != nil {
+				return err
+			}
+
+			innerErr := tx.Transaction(func(inner *ndb.DBBridge) error {
+				q := ndb.NewCreateQuery(trxUsersTable.PName).
+					Payload(ndb.M{"email": "inner@test.com"}).
+					Fields("id")
+				if _, err := inner.CreateOne(q); err != nil {
+					return err
+				}
+				return fmt.Errorf("force_inner_rollback")
+			})
+			if innerErr == nil || innerErr.Error() != "force_inner_rollback" {
+				return fmt.Errorf("inner_error_mismatch: %v", innerErr)
+			}
+
+			return tx.Transaction(func(inner *ndb.DBBridge) error {
+				q := ndb.NewCreateQuery(trxUsersTable.PName).
+					Payload(ndb.M{"email": "inner_ok@test.com"}).
+					Fields("id")
+				_, err := inner.CreateOne(q)
+				return err
+			})
+		})
+		if err != nil {
+			t.Fatalf("nested_transaction_error: %v", err)
+		}
+
+		after := countTrxUsers(t)
+		if after != before+2 {
+			t.Fatalf("nested_count_mismatch before=%d after=%d", before, after)
+		}
+	})
+
+	mustStep(t, "05_options_and_hooks", func(t *testing.T) {
+		var committed, rolledBack int
+
+		opts := &ndb.TxOptions{Isolation: sql.LevelSerializable, MaxRetries: 3}
+		err := bridge.TransactionWith(opts, func(tx *ndb.DBBridge) error {
+			tx.AfterCommit(func() { committed++ })
+			tx.AfterRollback(func(error) { rolledBack++ })
+
+			q := ndb.NewCreateQuery(trxUsersTable.PName).
+				Payload(ndb.M{"email": "hooks@test.com"}).
+				Fields("id")
+			_, err := tx.CreateOne(q)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("transaction_with_error: %v", err)
+		}
+		if committed != 1 || rolledBack != 0 {
+			t.Fatalf("commit_hooks_mismatch committed=%d rolled_back=%d", committed, rolledBack)
+		}
+
+		forced := fmt.Errorf("force_rollback")
+		err = bridge.TransactionWith(&ndb.TxOptions{ReadOnly: true}, func(tx *ndb.DBBridge) error {
+			tx.AfterCommit(func() { committed++ })
+			tx.AfterRollback(func(error) { rolledBack++ })
+			return forced
+		})
+		if !errors.Is(err, forced) {
+			t.Fatalf("expected callback error, got: %v", err)
+		}
+		if committed != 1 || rolledBack != 1 {
+			t.Fatalf("rollback_hooks_mismatch committed=%d rolled_back=%d", committed, rolledBack)
+		}
+	})
+
+	mustStep(t, "06_cleanup", func(t *testing.T) {
 		_ = bridge.DeleteSchema(trxUsersTable.PName)
 	})
 }
+
+// runs without a database: serialization failures are retried by default
+func TestTransactionRetries(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	preview := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+	conflict := &pq.Error{Code: "40001"}
+
+	attempts := 0
+	failing := func(tx *ndb.DBBridge) error {
+		attempts++
+		return conflict
+	}
+
+	mustStep(t, "01_default_retries", func(t *testing.T) {
+		err := preview.TransactionWith(&ndb.TxOptions{Isolation: sql.LevelSerializable, RetryBackoff: time.Millisecond}, failing)
+		if !errors.Is(err, conflict) || attempts != ndb.DefaultTxRetries+1 {
+			t.Fatalf("retries_mismatch attempts=%d err=%v", attempts, err)
+		}
+	})
+
+	mustStep(t, "02_plain_transaction_retries", func(t *testing.T) {
+		attempts = 0
+		err := preview.Transaction(func(tx *ndb.DBBridge) error {
+			if attempts++; attempts < 2 {
+				return conflict
+			}
+			return nil
+		})
+		if err != nil || attempts != 2 {
+			t.Fatalf("plain_retry_mismatch attempts=%d err=%v", attempts, err)
+		}
+		if last := dry.Last().SQL; last != "COMMIT" {
+			t.Fatalf("expected COMMIT, got: %q", last)
+		}
+	})
+
+	mustStep(t, "03_retries_disabled", func(t *testing.T) {
+		attempts = 0
+		err := preview.TransactionWith(&ndb.TxOptions{MaxRetries: ndb.NoTxRetries}, failing)
+		if !errors.Is(err, conflict) || attempts != 1 {
+			t.Fatalf("disabled_retries_mismatch attempts=%d err=%v", attempts, err)
+		}
+	})
+
+	mustStep(t, "04_stream_callers_not_retried", func(t *testing.T) {
+		if err := preview.CreateSchema(detached(usersTable)); err != nil {
+			t.Fatalf("create_schema_error: %v", err)
+		}
+
+		deadlock := &pq.Error{Code: "40P01"}
+		begins := func() (n int) {
+			for _, stmt := range dry.Statements() {
+				if stmt.SQL == "BEGIN" {
+					n++
+				}
+			}
+			return n
+		}
+
+		dry.Reset()
+		dry.Fail(func(stmt ndb.DryRunStatement) error {
+			if strings.HasPrefix(stmt.SQL, "COPY") && len(stmt.Args) != 0 {
+				return deadlock
+			}
+			return nil
+		})
+		in := strings.NewReader("public_id,email\n4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a01,retry@test.com\n")
+		if n, err := preview.CopyFrom(usersTable.PName, in, ndb.COPY_CSV); err == nil || n != 0 || begins() != 1 {
+			t.Fatalf("copy_from_retried n=%d begins=%d err=%v", n, begins(), err)
+		}
+
+		// 3 columns: two chunks, the second one deadlocks
+		payloads := make([]ndb.M, 65535/3+1)
+		for i := range payloads {
+			payloads[i] = ndb.M{"public_id": "4b0e8f3e-8b5e-4a44-9a9f-0c1f8d0b1a01", "email": "retry@test.com", "username": "retry"}
+		}
+		inserts := 0
+		dry.Reset()
+		dry.Fail(func(stmt ndb.DryRunStatement) error {
+			if strings.HasPrefix(stmt.SQL, "INSERT") {
+				if inserts++; inserts == 2 {
+					return deadlock
+				}
+			}
+			return nil
+		})
+		if _, err := preview.CreateMany(ndb.NewCreateQuery(usersTable.PName).Payloads(payloads)); err == nil || begins() != 1 || inserts != 2 {
+			t.Fatalf("create_many_retried inserts=%d begins=%d err=%v", inserts, begins(), err)
+		}
+		dry.Fail(nil)
+	})
+}
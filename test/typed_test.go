@@ -0,0 +1,127 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+// runs on a dry-run bridge: the canned rows are scanned straight into the structs
+func TestTypedHelpers(t *testing.T) {
+	db, dry := ndb.NewDryRunDB()
+	defer db.Close()
+
+	bridge := ndb.NewBridge(&ndb.NBridge{DB: db, SchemaPrefix: "ndb_", SchemaStorage: tempStorage(t)})
+
+	var events []ndb.QueryEvent
+	bridge.AfterExecute(func(e ndb.QueryEvent) { events = append(events, e) })
+
+	createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	mustStep(t, "01_setup_schema", func(t *testing.T) {
+		if err := bridge.CreateSchema(detached(usersTable)); err != nil {
+			t.Fatalf("create_schema_error: %v", err)
+		}
+	})
+
+	mustStep(t, "02_read_all_and_first", func(t *testing.T) {
+		dry.SetRows(
+			ndb.M{"id": 1, "email": "a@test.com", "created_at": createdAt, "status": "active"},
+			ndb.M{"id": 2, "email": "b@test.com", "created_at": createdAt, "status": "active"},
+		)
+
+		users, err := ndb.ReadAll[User](bridge, ndb.NewReadQuery(usersTable.PName).Where(ndb.M{"status": "active"}))
+		if err != nil {
+			t.Fatalf("read_all_error: %v", err)
+		}
+		if len(users) != 2 || users[1].ID != 2 || users[1].Email != "b@test.com" || !users[0].CreatedAt.Equal(createdAt) {
+			t.Fatalf("read_all_mismatch users=%+v", users)
+		}
+
+		first, err := ndb.ReadFirst[User](bridge, ndb.NewReadQuery(usersTable.PName).Fields("id", "email"))
+		if err != nil {
+			t.Fatalf("read_first_error: %v", err)
+		}
+		if first.ID != 1 || !strings.HasSuffix(dry.Last().SQL, "LIMIT 1") {
+			t.Fatalf("read_first_mismatch user=%+v sql=%q", first, dry.Last().SQL)
+		}
+
+		dry.SetRows()
+		if _, err := ndb.ReadFirst[User](bridge, ndb.NewReadQuery(usersTable.PName)); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("expected sql.ErrNoRows, got: %v", err)
+		}
+	})
+
+	mustStep(t, "03_create_and_update_returning", func(t *testing.T) {
+		dry.SetRows(ndb.M{"id": 9, "email": "new@test.com", "username": "new"})
+
+		created, err := ndb.CreateReturning[User](bridge, ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "new@test.com", "username": "new"}).
+			Fields("id", "email", "username"))
+		if err != nil {
+			t.Fatalf("create_returning_error: %v", err)
+		}
+		if len(created) != 1 || created[0].ID != 9 || created[0].Username != "new" {
+			t.Fatalf("create_returning_mismatch users=%+v", created)
+		}
+		if !strings.HasPrefix(dry.Last().SQL, `INSERT INTO "ndb_users"`) {
+			t.Fatalf("create_sql_mismatch sql=%q", dry.Last().SQL)
+		}
+
+		dry.SetRows(ndb.M{"id": 9, "email": "new@test.com", "username": "renamed"})
+
+		updated, err := ndb.UpdateReturning[User](bridge, ndb.NewUpdateQuery(usersTable.PName).
+			Payload(ndb.M{"username": "renamed"}).
+			Where(ndb.M{"id": 9}))
+		if err != nil {
+			t.Fatalf("update_returning_error: %v", err)
+		}
+		if len(updated) != 1 || updated[0].Username != "renamed" {
+			t.Fatalf("update_returning_mismatch users=%+v", updated)
+		}
+		if !strings.HasPrefix(dry.Last().SQL, `UPDATE "ndb_users"`) {
+			t.Fatalf("update_sql_mismatch sql=%q", dry.Last().SQL)
+		}
+	})
+
+	mustStep(t, "04_events_and_target_type", func(t *testing.T) {
+		last := events[len(events)-1]
+		if last.Query == nil || last.RowsAffected != 1 || last.Err != nil {
+			t.Fatalf("update_event_mismatch event=%+v", last)
+		}
+
+		if _, err := ndb.ReadAll[ndb.M](bridge, ndb.NewReadQuery(usersTable.PName)); err == nil {
+			t.Fatalf("expected unsupported target error")
+		}
+	})
+
+	mustStep(t, "05_nullable_pointers_and_arrays", func(t *testing.T) {
+		type row struct {
+			Username *string   `json:"username"`
+			Scores   []int64   `json:"scores"`
+			Weights  []float64 `json:"weights"`
+			Flags    []bool    `json:"flags"`
+		}
+
+		dry.SetRows(
+			ndb.M{"username": "named", "scores": "{1,2}", "weights": "{0.5}", "flags": "{t,f}"},
+			ndb.M{"username": nil, "scores": nil, "weights": nil, "flags": nil},
+		)
+
+		rows, err := ndb.ReadAll[row](bridge, ndb.NewReadQuery(usersTable.PName))
+		if err != nil {
+			t.Fatalf("read_all_error: %v", err)
+		}
+		if rows[0].Username == nil || *rows[0].Username != "named" || len(rows[0].Scores) != 2 || rows[0].Weights[0] != 0.5 || !rows[0].Flags[0] {
+			t.Fatalf("scan_mismatch row=%+v", rows[0])
+		}
+		if rows[1].Username != nil || rows[1].Scores != nil {
+			t.Fatalf("null_scan_mismatch row=%+v", rows[1])
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nitsugaro/go-ndb"
+)
+
+func TestUpsert(t *testing.T) {
+	mustStep(t, "01_reset_schemas", func(t *testing.T) {
+		resetSchemas(t)
+	})
+
+	mustStep(t, "02_on_conflict_do_update", func(t *testing.T) {
+		first := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "upsert@test.com", "username": "before"}).
+			Fields("id", "username")
+		created, err := bridge.CreateOne(first)
+		if err != nil {
+			t.Fatalf("create_error: %v", err)
+		}
+
+		upsert := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "upsert@test.com", "username": "after", "status": "blocked"}).
+			Fields("id", "username", "status").
+			OnConflict("email").DoUpdate("username", "status").DoneConflict()
+
+		updated, err := bridge.CreateOne(upsert)
+		if err != nil {
+			t.Fatalf("upsert_error: %v", err)
+		}
+		if updated["id"] != created["id"] || updated["username"] != "after" || updated["status"] != "blocked" {
+			t.Fatalf("upsert_mismatch created=%v updated=%v", created, updated)
+		}
+	})
+
+	mustStep(t, "03_on_conflict_do_update_where", func(t *testing.T) {
+		upsert := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "upsert@test.com", "username": "ignored"}).
+			Fields("id").
+			OnConflict("email").DoUpdate("username").Where(ndb.M{"status": "active"}).DoneConflict()
+
+		rows, err := bridge.Create(upsert)
+		if err != nil {
+			t.Fatalf("upsert_where_error: %v", err)
+		}
+		if len(rows) != 0 {
+			t.Fatalf("expected no updated rows, got %v", rows)
+		}
+	})
+
+	mustStep(t, "04_create_many_on_conflict_do_nothing", func(t *testing.T) {
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payloads([]ndb.M{
+				{"public_id": uuid.NewString(), "email": "upsert@test.com"},
+				{"public_id": uuid.NewString(), "email": "upsert_new@test.com"},
+			}).
+			Fields("email").
+			OnConflict("email").DoNothing().DoneConflict()
+
+		rows, err := bridge.CreateMany(q)
+		if err != nil {
+			t.Fatalf("create_many_do_nothing_error: %v", err)
+		}
+		if len(rows) != 1 || rows[0]["email"] != "upsert_new@test.com" {
+			t.Fatalf("create_many_do_nothing_mismatch: %v", rows)
+		}
+	})
+
+	mustStep(t, "05_rejects_non_unique_conflict_target", func(t *testing.T) {
+		q := ndb.NewCreateQuery(usersTable.PName).
+			Payload(ndb.M{"public_id": uuid.NewString(), "email": "other@test.com"}).
+			OnConflict("status").DoNothing().DoneConflict()
+
+		if _, err := bridge.Create(q); !errors.Is(err, ndb.ErrInvalidConflict) {
+			t.Fatalf("expected ErrInvalidConflict, got: %v", err)
+		}
+	})
+}
@@ -1,15 +1,187 @@
 package ndb
 
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DefaultTxRetries is how many times a transaction is re-run after a serialization
+// failure or a deadlock when TxOptions.MaxRetries is zero, including plain Transaction calls.
+const DefaultTxRetries = 3
+
+// NoTxRetries as TxOptions.MaxRetries runs the transaction once and returns its error.
+const NoTxRetries = -1
+
+// TxOptions configures a transaction started with TransactionWith.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// MaxRetries is how many times the whole transaction is re-run after a
+	// serialization failure (40001) or a deadlock (40P01). Zero uses
+	// DefaultTxRetries; NoTxRetries (or any negative value) disables retries.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled on every attempt. Defaults to 50ms.
+	RetryBackoff time.Duration
+}
+
+type txHooks struct {
+	afterCommit   []func()
+	afterRollback []func(err error)
+	// compensations undo work done outside the database, latest first
+	compensations []func()
+	// wrote opens the read-your-writes window once the transaction commits
+	wrote bool
+}
+
 func (dbb *DBBridge) Transaction(tfunc func(bridge *DBBridge) error) error {
-	trx, err := dbb.db.Begin()
+	return dbb.TransactionWith(nil, tfunc)
+}
+
+// TransactionWith runs tfunc inside a transaction started with opts. When called
+// on a transaction bridge it opens a savepoint instead and opts are ignored.
+func (dbb *DBBridge) TransactionWith(opts *TxOptions, tfunc func(bridge *DBBridge) error) error {
+	if dbb.trx != nil {
+		return dbb.savepoint(tfunc)
+	}
+
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = 50 * time.Millisecond
+	}
+
+	retries := opts.MaxRetries
+	if retries == 0 {
+		retries = DefaultTxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := dbb.runTransaction(opts, tfunc)
+		if err == nil || attempt >= retries || !isRetryableTxError(err) {
+			return err
+		}
+
+		select {
+		case <-dbb.Context().Done():
+			return dbb.Context().Err()
+		case <-time.After(backoff << attempt):
+		}
+	}
+}
+
+func (dbb *DBBridge) runTransaction(opts *TxOptions, tfunc func(bridge *DBBridge) error) error {
+	trx, err := dbb.db.BeginTx(dbb.Context(), &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
 	if err != nil {
 		return err
 	}
 
-	tempBridge := NewBridge(&NBridge{trx: trx, prevValidatemiddlewares: dbb.prevValidate, postValidatemiddlewares: dbb.postValidate, SchemaPrefix: dbb.schemaPrefix, SchemaStorage: dbb.schemaStorage})
+	tempBridge := NewBridge(&NBridge{trx: trx, ctx: dbb.ctx, hooks: &txHooks{}, prevValidatemiddlewares: dbb.prevValidate, postValidatemiddlewares: dbb.postValidate, afterExecuteHooks: dbb.afterExecute, SchemaPrefix: dbb.schemaPrefix, SchemaStorage: dbb.schemaStorage, CursorSecret: dbb.cursorSecret, router: dbb.router, stmts: dbb.stmts, shapes: dbb.shapes})
 	if err := tfunc(tempBridge); err != nil {
-		return tempBridge.trx.Rollback()
+		if rbErr := trx.Rollback(); rbErr != nil {
+			err = errors.Join(err, rbErr)
+		}
+		tempBridge.hooks.runAfterRollback(err)
+		return err
 	}
 
-	return tempBridge.trx.Commit()
+	if err := trx.Commit(); err != nil {
+		err = dbb.classifyError(err)
+		tempBridge.hooks.runAfterRollback(err)
+		return err
+	}
+	if tempBridge.hooks.wrote {
+		dbb.openWriteWindow()
+	}
+
+	tempBridge.hooks.runAfterCommit()
+	return nil
+}
+
+// savepoint runs tfunc inside a SAVEPOINT of the current transaction, so nested
+// Transaction calls roll back only their own work when they fail.
+func (dbb *DBBridge) savepoint(tfunc func(bridge *DBBridge) error) error {
+	name := "ndb_sp_" + strconv.Itoa(dbb.depth+1)
+
+	if _, err := dbb.execQuery("SAVEPOINT " + name); err != nil {
+		return err
+	}
+
+	var migration *migrationLog
+	if dbb.migration != nil {
+		migration = &migrationLog{replay: dbb.migration.replay}
+	}
+
+	tempBridge := NewBridge(&NBridge{trx: dbb.trx, ctx: dbb.ctx, depth: dbb.depth + 1, migration: migration, hooks: &txHooks{}, prevValidatemiddlewares: dbb.prevValidate, postValidatemiddlewares: dbb.postValidate, afterExecuteHooks: dbb.afterExecute, SchemaPrefix: dbb.schemaPrefix, SchemaStorage: dbb.schemaStorage, CursorSecret: dbb.cursorSecret, router: dbb.router, stmts: dbb.stmts, shapes: dbb.shapes})
+	if err := tfunc(tempBridge); err != nil {
+		if _, rbErr := dbb.execQuery("ROLLBACK TO SAVEPOINT " + name); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		tempBridge.hooks.runAfterRollback(err)
+		return err
+	}
+
+	if _, err := dbb.execQuery("RELEASE SAVEPOINT " + name); err != nil {
+		return err
+	}
+
+	// released work belongs to the parent now: its hooks fire with the outer outcome
+	dbb.hooks.afterCommit = append(dbb.hooks.afterCommit, tempBridge.hooks.afterCommit...)
+	dbb.hooks.afterRollback = append(dbb.hooks.afterRollback, tempBridge.hooks.afterRollback...)
+	dbb.hooks.compensations = append(dbb.hooks.compensations, tempBridge.hooks.compensations...)
+	dbb.hooks.wrote = dbb.hooks.wrote || tempBridge.hooks.wrote
+	if migration != nil {
+		dbb.migration.ddl = append(dbb.migration.ddl, migration.ddl...)
+		dbb.migration.changes = append(dbb.migration.changes, migration.changes...)
+	}
+	return nil
+}
+
+// AfterCommit registers fn to run once the enclosing transaction commits. Outside
+// a transaction the data is already durable, so fn runs immediately.
+func (dbb *DBBridge) AfterCommit(fn func()) {
+	if dbb.hooks == nil {
+		fn()
+		return
+	}
+	dbb.hooks.afterCommit = append(dbb.hooks.afterCommit, fn)
+}
+
+// AfterRollback registers fn to run with the failure cause when the enclosing
+// transaction (or savepoint) is rolled back. Outside a transaction it is a no-op.
+func (dbb *DBBridge) AfterRollback(fn func(err error)) {
+	if dbb.hooks == nil {
+		return
+	}
+	dbb.hooks.afterRollback = append(dbb.hooks.afterRollback, fn)
+}
+
+func (h *txHooks) runAfterCommit() {
+	for _, fn := range h.afterCommit {
+		fn()
+	}
+}
+
+func (h *txHooks) runAfterRollback(err error) {
+	for _, fn := range slices.Backward(h.compensations) {
+		fn()
+	}
+	for _, fn := range h.afterRollback {
+		fn(err)
+	}
+}
+
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
 }